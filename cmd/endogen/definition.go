@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/semrekkers/endo/pkg/endo"
 )
 
 // definition represents a schema to generate code for.
@@ -23,6 +25,8 @@ type definition struct {
 	Store               string
 	GenerateStore       bool
 	ReadOnly            bool
+	Dialect             *endo.Dialect
+	DialectIdent        string // identifier of Dialect in package endo
 	Models              []*model
 }
 
@@ -46,10 +50,12 @@ type model struct {
 	Plural        string // plural of name
 	Table         string // table name in database
 	Sort          string // sort order to use for result set, if any
+	SortField     *field // field of Sort if it's a single column (instead of an SQL expression)
 
 	Patch *model // patch type of this model
 
 	fields []*field
+	keys   []*field // primary key fields
 }
 
 type field struct {
@@ -57,6 +63,10 @@ type field struct {
 	Column   string // column name in model
 	Type     string // field type in source code
 	ReadOnly bool   // whether this field is read-only
+	Primary  bool   // whether this field is (part of) the primary key
+
+	implicitColumn bool // whether Column is the field name, because it has no db tag
+	integer        bool // whether the type of this field is an integer type
 }
 
 // Fields returns the fields of the model. If forWrite is true, only
@@ -73,6 +83,52 @@ func (m *model) Fields(forWrite bool) []*field {
 	return fields[:n]
 }
 
+// Keys returns the primary key fields of the model.
+func (m *model) Keys() []*field {
+	return m.keys
+}
+
+// CompositeKey returns whether the primary key of the model consists of multiple fields.
+func (m *model) CompositeKey() bool {
+	return 1 < len(m.keys)
+}
+
+// KeyType returns the type of the primary key. For a composite key this is the generated key type.
+func (m *model) KeyType() string {
+	if m.CompositeKey() {
+		return m.Name + "Key"
+	}
+	return m.keys[0].Type
+}
+
+// GeneratedKey returns whether the primary key is a single read-only field, which
+// is generated by the database.
+func (m *model) GeneratedKey() bool {
+	return len(m.keys) == 1 && m.keys[0].ReadOnly
+}
+
+// KeyRefs returns the expressions referring to the primary key values of a key variable named v.
+func (m *model) KeyRefs(v string) []string {
+	if !m.CompositeKey() {
+		return []string{v}
+	}
+	refs := make([]string, len(m.keys))
+	for i, key := range m.keys {
+		refs[i] = v + "." + key.Name
+	}
+	return refs
+}
+
+// HasField returns whether the model has a field with the given name.
+func (m *model) HasField(name string) bool {
+	for _, field := range m.fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Updatable returns whether m is updatable by patch or replacement.
 func (m *model) Updatable() bool {
 	return !(m.ReadOnly || m.Immutable || m.Patches != "")
@@ -169,8 +225,9 @@ func (d *definition) addModel(name, comment string, s *ast.StructType) error {
 	}
 
 	for _, field := range s.Fields.List {
-		c := field
-		m.addFields(d, c)
+		if err := m.addFields(d, field); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
 	}
 
 	d.Models = append(d.Models, &m)
@@ -219,6 +276,33 @@ func (d *definition) resolveModelDependencies(createMissing bool) error {
 	return nil
 }
 
+// checkDialect checks whether every model can be generated for the dialect of the definition.
+func (d *definition) checkDialect() error {
+	if d.Dialect.Returning {
+		return nil
+	}
+	// Without RETURNING, mutated records are queried again by their primary key.
+	for _, m := range d.Models {
+		if m.ReadOnly {
+			continue
+		}
+		if len(m.keys) == 0 {
+			return fmt.Errorf("type (%s) requires a primary key for dialect %s", m.Type, d.Dialect.Name)
+		}
+		if m.GeneratedKey() && !m.keys[0].integer {
+			return fmt.Errorf("type (%s) requires an integer read-only primary key field (%s) for dialect %s", m.Type, m.keys[0].Name, d.Dialect.Name)
+		}
+		if m.CompositeKey() {
+			for _, key := range m.keys {
+				if key.ReadOnly {
+					return fmt.Errorf("type (%s) cannot have a read-only composite primary key field (%s) for dialect %s", m.Type, key.Name, d.Dialect.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func (d *definition) newPatchTypeOf(b *model) *model {
 	name := b.Type + "Patch"
 	m := &model{
@@ -232,9 +316,10 @@ func (d *definition) newPatchTypeOf(b *model) *model {
 			continue
 		}
 		m.fields = append(m.fields, &field{
-			Name:   bField.Name,
-			Column: bField.Column,
-			Type:   "*" + bField.Type, // pointer type
+			Name:           bField.Name,
+			Column:         bField.Column,
+			Type:           "*" + bField.Type, // pointer type
+			implicitColumn: bField.implicitColumn,
 		})
 	}
 	return m
@@ -248,12 +333,12 @@ func (m *model) addFields(d *definition, f *ast.Field) error {
 	typeString := sprintNode(fieldType)
 
 	var (
-		column         string
-		readOnly, sort bool
+		column                  string
+		readOnly, sort, primary bool
 	)
 	if f.Tag != nil {
 		// Parse the struct tag.
-		// Example tag: `db:"column,readonly,sort"`.
+		// Example tag: `db:"column,primary,readonly,sort"`.
 		tag := reflect.StructTag(f.Tag.Value[1 : len(f.Tag.Value)-1]).Get("db")
 		parts := strings.Split(tag, ",")
 		column = parts[0]
@@ -266,6 +351,8 @@ func (m *model) addFields(d *definition, f *ast.Field) error {
 				readOnly = true
 			case "sort":
 				sort = true
+			case "primary":
+				primary = true
 			}
 		}
 	}
@@ -290,13 +377,21 @@ func (m *model) addFields(d *definition, f *ast.Field) error {
 			Column:   column,
 			Type:     typeString,
 			ReadOnly: readOnly,
+			Primary:  primary,
+			integer:  isIntegerType(f.Type),
 		}
 		if spec.Column == "" {
 			spec.Column = spec.Name
+			spec.implicitColumn = true
+		}
+
+		if primary {
+			m.keys = append(m.keys, spec)
 		}
 
 		if sort && m.Sort == "" {
 			m.Sort = spec.Column
+			m.SortField = spec
 		}
 
 		m.fields = append(m.fields, spec)
@@ -305,6 +400,24 @@ func (m *model) addFields(d *definition, f *ast.Field) error {
 	return nil
 }
 
+// isIntegerType returns whether the type expression is an integer type, or a local type
+// declared as one.
+func isIntegerType(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	if ident.Obj != nil {
+		typeSpec, ok := ident.Obj.Decl.(*ast.TypeSpec)
+		return ok && isIntegerType(typeSpec.Type)
+	}
+	switch ident.Name {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
 var errNoEmbeddedStruct = errors.New("no embedded struct field")
 
 // addEmbeddedStructFields adds any fields from an embedded struct field (flatten).
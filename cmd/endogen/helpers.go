@@ -4,36 +4,119 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/semrekkers/endo/pkg/endo"
+)
+
+// joinStrings joins the given strings with a separator.
+func joinStrings(sep string, a []string) string {
+	return strings.Join(a, sep)
+}
+
+// literal returns s as Go string literal. A raw string literal is preferred.
+func literal(s string) string {
+	if strings.ContainsRune(s, '`') {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+const (
+	quoteModeAuto = "auto" // quote identifiers only when required
+	quoteModeAll  = "all"  // quote all identifiers
+	quoteModeNone = "none" // never quote identifiers
 )
 
-// toColumns returns a list of column names of fields.
-func toColumns(fields []*field) []string {
+// dialectHelpers contains the helpers which depend on the SQL dialect.
+type dialectHelpers struct {
+	dialect   *endo.Dialect
+	ident     string // identifier of dialect in package endo
+	quoteMode string
+}
+
+// quoteIdent returns the identifier s, quoted according to the quote mode. A qualified
+// identifier, like "schema.table", is quoted per part.
+func (h *dialectHelpers) quoteIdent(s string) string {
+	if h.quoteMode == quoteModeNone {
+		return s
+	}
+	parts := strings.Split(s, ".")
+	for i, part := range parts {
+		if h.quoteMode == quoteModeAll || h.dialect.NeedsQuote(part) {
+			parts[i] = h.dialect.QuoteIdent(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// column returns the (quoted) column name of f. In auto mode, the implicit column name of
+// a field without a db tag isn't quoted, so it's matched case-insensitively like before.
+func (h *dialectHelpers) column(f *field) string {
+	if f.implicitColumn && h.quoteMode == quoteModeAuto {
+		return f.Column
+	}
+	return h.quoteIdent(f.Column)
+}
+
+// toColumns returns a list of (quoted) column names of fields.
+func (h *dialectHelpers) toColumns(fields []*field) []string {
 	columns := make([]string, len(fields))
 	for i := range fields {
-		columns[i] = fields[i].Column
+		columns[i] = h.column(fields[i])
 	}
 	return columns
 }
 
-// joinStrings joins the given strings with a separator.
-func joinStrings(sep string, a []string) string {
-	return strings.Join(a, sep)
+// param returns the placed parameter with index i.
+func (h *dialectHelpers) param(i int) string {
+	var b endo.Builder
+	h.dialect.FormatParam(&b, i)
+	return b.String()
+}
+
+// formatParams returns query with its "{}" parameters placed, like a Builder places the
+// parameters of its first arguments.
+func (h *dialectHelpers) formatParams(query string) (string, error) {
+	t := endo.NewTemplate(query)
+	query, _, err := t.Render(h.dialect, make([]interface{}, t.NumParams())...)
+	return query, err
 }
 
 // mapToParams returns a list of placed parameters based on a.
-func mapToParams(a []string) []string {
+func (h *dialectHelpers) mapToParams(a []string) []string {
 	v := make([]string, len(a))
 	for i := range a {
-		v[i] = "$" + strconv.Itoa(i+1)
+		v[i] = h.param(i)
 	}
 	return v
 }
 
-// toFieldUpdates maps a to "<fieldName> = $<placedParameter>".
-func toFieldUpdates(a []string) []string {
+// toFieldUpdates maps a to "<fieldName> = <placedParameter>".
+func (h *dialectHelpers) toFieldUpdates(a []string) []string {
+	return h.toFieldUpdatesFrom(0, a)
+}
+
+// toFieldUpdatesFrom maps a to "<fieldName> = <placedParameter>", where the
+// placed parameters start after offset.
+func (h *dialectHelpers) toFieldUpdatesFrom(offset int, a []string) []string {
+	v := make([]string, len(a))
+	for i, field := range a {
+		v[i] = fmt.Sprintf("%s = %s", field, h.param(offset+i))
+	}
+	return v
+}
+
+// newBuilder returns the statements acquiring a pooled *endo.Builder named qb for the dialect,
+// which is released when the function returns.
+func (h *dialectHelpers) newBuilder() string {
+	return "qb := endo.AcquireBuilder(endo." + h.ident + ")\n\tdefer endo.ReleaseBuilder(qb)"
+}
+
+// toBuilderParams maps a to "<fieldName> = {}", to be used with endo.Builder.
+func toBuilderParams(a []string) []string {
 	v := make([]string, len(a))
 	for i, field := range a {
-		v[i] = fmt.Sprintf("%s = $%d", field, i+1)
+		v[i] = field + " = {}"
 	}
 	return v
 }
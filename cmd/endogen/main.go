@@ -11,8 +11,11 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 
+	"github.com/semrekkers/endo/pkg/endo"
+
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/imports"
 )
@@ -36,6 +39,8 @@ func main() {
 		argPkgName       = flag.String("pkg", "", "Package `name` to use in the output (default use package name from input)")
 		argImportAlias   = flag.String("import-alias", "", "Alias `name` to use for the imported external package of input")
 		argOutput        = flag.String("out", "", "Output `file` to write the result to (default writes to stdout)")
+		argDialect       = flag.String("dialect", "postgres", "SQL `dialect` of the generated queries [postgres, mysql, sqlite]")
+		argQuoteMode     = flag.String("quote", "auto", "Quote `mode` of table and column identifiers in the generated queries [auto, all, none]")
 	)
 	flag.Parse()
 	switch *argPatchTypeMode {
@@ -45,6 +50,17 @@ func main() {
 	default:
 		exitOnErr(fmt.Errorf("patch flag value can only be one of: include, only or import, not %s", *argPatchTypeMode))
 	}
+	switch *argQuoteMode {
+	case quoteModeAuto, quoteModeAll, quoteModeNone:
+		break
+
+	default:
+		exitOnErr(fmt.Errorf("quote flag value can only be one of: auto, all or none, not %s", *argQuoteMode))
+	}
+	dialect, ok := dialects[*argDialect]
+	if !ok {
+		exitOnErr(fmt.Errorf("dialect flag value can only be one of: postgres, mysql or sqlite, not %s", *argDialect))
+	}
 
 	var (
 		fset          = token.NewFileSet()
@@ -69,11 +85,13 @@ func main() {
 	sourcePackageName := source.Name.String()
 	d := definition{
 		Package:       sourcePackageName,
-		Imports:       baseImports,
+		Imports:       append(baseImports, dialect.imports...),
 		PatchTypeMode: *argPatchTypeMode,
 		Store:         *argStoreType,
 		GenerateStore: *argGenStore,
 		ReadOnly:      *argViews,
+		Dialect:       dialect.dialect,
+		DialectIdent:  dialect.ident,
 	}
 	if *argPkgName != "" {
 		d.Package = *argPkgName
@@ -107,9 +125,10 @@ func main() {
 		}
 	}
 	exitOnErr(d.resolveModelDependencies(*argPatchTypeMode != patchTypeModeImport))
+	exitOnErr(d.checkDialect())
 
 	var (
-		templates   = getTemplates()
+		templates   = getTemplates(&dialectHelpers{dialect: d.Dialect, ident: d.DialectIdent, quoteMode: *argQuoteMode})
 		runTemplate = "store.go.tmpl"
 		buf         bytes.Buffer
 	)
@@ -135,17 +154,52 @@ func main() {
 var baseImports = []*importInfo{
 	{Path: "context"},
 	{Path: "database/sql"},
-	{Path: "github.com/lib/pq"},
 	{Path: "github.com/semrekkers/endo/pkg/endo"},
 }
 
-func getTemplates() *template.Template {
+type dialectInfo struct {
+	dialect *endo.Dialect
+	ident   string        // identifier of dialect in package endo
+	imports []*importInfo // additional imports for the dialect
+}
+
+var dialects = map[string]*dialectInfo{
+	"postgres": {
+		dialect: endo.Postgres,
+		ident:   "Postgres",
+		imports: []*importInfo{{Path: "github.com/lib/pq"}},
+	},
+	"mysql": {
+		dialect: endo.MySQL,
+		ident:   "MySQL",
+	},
+	"sqlite": {
+		dialect: endo.SQLite,
+		ident:   "SQLite",
+	},
+}
+
+func getTemplates(h *dialectHelpers) *template.Template {
 	v := template.New("endogen")
 	v.Funcs(template.FuncMap{
-		"toColumns":      toColumns,
-		"joinStrings":    joinStrings,
-		"mapToParams":    mapToParams,
-		"toFieldUpdates": toFieldUpdates,
+		"render": func(name string, data interface{}) (string, error) {
+			var buf strings.Builder
+			err := v.ExecuteTemplate(&buf, name, data)
+			return buf.String(), err
+		},
+		"literal":            literal,
+		"ident":              h.quoteIdent,
+		"column":             h.column,
+		"toColumns":          h.toColumns,
+		"joinStrings":        joinStrings,
+		"param":              h.param,
+		"formatParams":       h.formatParams,
+		"mapToParams":        h.mapToParams,
+		"toFieldUpdates":     h.toFieldUpdates,
+		"toFieldUpdatesFrom": h.toFieldUpdatesFrom,
+		"toBuilderParams":    toBuilderParams,
+		"newBuilder":         h.newBuilder,
+		"dialect":            func() *endo.Dialect { return h.dialect },
 	})
 	_, err := v.ParseFS(templateFS, "templates/*")
 	if err != nil {
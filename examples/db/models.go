@@ -9,7 +9,7 @@ import (
 
 // User represents an application user.
 type User struct {
-	ID            int            `db:"id,readonly,sort"`
+	ID            int            `db:"id,primary,readonly,sort"`
 	Email         string         `db:"email"`
 	FirstName     sql.NullString `db:"first_name"`
 	LastName      sql.NullString `db:"last_name"`
@@ -24,6 +24,14 @@ type User struct {
 
 // Role represents an application role.
 type Role struct {
-	ID   int    `db:"id,readonly,sort"`
+	ID   int    `db:"id,primary,readonly,sort"`
 	Name string `db:"name"`
 }
+
+// UserRole (table: user_roles) represents a role assigned to a user.
+//
+// sort: "user_id, role_id"
+type UserRole struct {
+	UserID int `db:"user_id,primary"`
+	RoleID int `db:"role_id,primary"`
+}
@@ -6,7 +6,7 @@ package db
 //
 // sort: "user_id, role_id"
 type EffectiveRole struct {
-	// UserID   int    `db:"user_id"`
-	RoleID   int    `db:"role_id"`
+	UserID   int    `db:"user_id,primary"`
+	RoleID   int    `db:"role_id,primary"`
 	RoleName string `db:"role_name"`
 }
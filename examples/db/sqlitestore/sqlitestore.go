@@ -0,0 +1,5 @@
+// Package sqlitestore contains the store of the models in package db, generated for SQLite.
+package sqlitestore
+
+//go:generate endogen -dialect sqlite -pkg sqlitestore -import .. -out store.go ../models.go
+//go:generate endogen -dialect sqlite -pkg sqlitestore -import .. -gen-store=false --views -out store_views.go ../models_views.go
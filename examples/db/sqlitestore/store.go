@@ -0,0 +1,1082 @@
+// Code generated by endogen; DO NOT EDIT.
+
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/semrekkers/endo/examples/db"
+	"github.com/semrekkers/endo/pkg/endo"
+)
+
+// Store manages the set of APIs for database access.
+type Store struct {
+	TX endo.TxFunc
+}
+
+const (
+	// querySelectUser is a prepared SQL query for selecting a User.
+	querySelectUser = `SELECT id, email, first_name, last_name, display_name, email_verified, password_hash, created_at, updated_at FROM users `
+	// queryReturnUser can be used as a part of a SQL query for returning a User.
+	queryReturnUser = ` RETURNING id, email, first_name, last_name, display_name, email_verified, password_hash, created_at, updated_at`
+	// querySortUser is the default sorting order of User.
+	querySortUser = ` ORDER BY id `
+	// queryFirstUser is the precompiled query for selecting the first User without filters.
+	queryFirstUser = querySelectUser + querySortUser + "LIMIT 1"
+	// queryPageUser is the precompiled query for selecting a page of Users without filters,
+	// its arguments are the limit and offset.
+	queryPageUser = querySelectUser + querySortUser + `LIMIT ?1 OFFSET ?2`
+)
+
+// templatePageUser is the precompiled default sorting and page bounds of Users with filters.
+var templatePageUser = endo.NewTemplate(querySortUser + `LIMIT {} OFFSET {}`)
+
+// GetUser retrieves the User with the given primary key. If no User was found, endo.ErrNotFound is returned.
+func (s *Store) GetUser(ctx context.Context, key int) (*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpSelect, Method: "Store.GetUser"})
+	const query = querySelectUser + `WHERE id = ?1`
+
+	var e db.User
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, key)
+		return scanUser(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("User", "Store.GetUser", query, err)
+	}
+
+	return &e, nil
+}
+
+// FindUser retrieves the first User with the filters applied. The default sorting of User is used.
+func (s *Store) FindUser(ctx context.Context, filters ...endo.Filter) (*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpSelect, Method: "Store.FindUser"})
+	query := queryFirstUser
+	var args []interface{}
+	if 0 < len(filters) {
+		qb := endo.AcquireBuilder(endo.SQLite)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectUser).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.Write(querySortUser + "LIMIT 1")
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("User", "Store.FindUser", query, err)
+		}
+	}
+
+	var e db.User
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, args...)
+		return scanUser(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("User", "Store.FindUser", query, err)
+	}
+
+	return &e, nil
+}
+
+// GetUsers retrieves all Users with the filters applied, within the bounds of the page.
+// The default sorting of User is used.
+func (s *Store) GetUsers(ctx context.Context, po endo.PageOptions, filters ...endo.Filter) ([]*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpSelect, Method: "Store.GetUsers"})
+	limit, offset := po.Args()
+	query, args := queryPageUser, []interface{}{limit, offset}
+	if 0 < len(filters) {
+		qb := endo.AcquireBuilder(endo.SQLite)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectUser).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.WriteTemplate(templatePageUser, limit, offset)
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("User", "Store.GetUsers", query, err)
+		}
+	}
+
+	var c []*db.User
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanUserRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("User", "Store.GetUsers", query, err)
+}
+
+// CreateUser inserts a User record. On success, it returns the created record.
+func (s *Store) CreateUser(ctx context.Context, in db.User) (*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpInsert, Method: "Store.CreateUser"})
+	const query = `INSERT INTO users (email, first_name, last_name, email_verified, password_hash, created_at, updated_at) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7) ` +
+		queryReturnUser
+
+	var e db.User
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query,
+			in.Email,
+			in.FirstName,
+			in.LastName,
+			in.EmailVerified,
+			in.PasswordHash,
+			in.CreatedAt,
+			in.UpdatedAt,
+		)
+		return scanUser(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("User", "Store.CreateUser", query, err)
+	}
+
+	return &e, nil
+}
+
+// UpdateUsers updates all Users that satisfy the condition of filters. The default sorting of User is used.
+// On success, it returns the updated records.
+func (s *Store) UpdateUsers(ctx context.Context, in db.User, filters ...endo.Filter) ([]*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpUpdate, Method: "Store.UpdateUsers"})
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.WriteWithArgs(`UPDATE users SET email = ?1, first_name = ?2, last_name = ?3, email_verified = ?4, password_hash = ?5, created_at = ?6, updated_at = ?7 `,
+		in.Email,
+		in.FirstName,
+		in.LastName,
+		in.EmailVerified,
+		in.PasswordHash,
+		in.CreatedAt,
+		in.UpdatedAt,
+	)
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+	}
+	qb.Write(queryReturnUser)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("User", "Store.UpdateUsers", query, err)
+	}
+
+	var c []*db.User
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanUserRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("User", "Store.UpdateUsers", query, err)
+}
+
+// UpdateUser updates the User with the given primary key. If no User was found, endo.ErrNotFound is returned.
+// On success, it returns the updated record.
+func (s *Store) UpdateUser(ctx context.Context, key int, in db.User) (*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpUpdate, Method: "Store.UpdateUser"})
+	const query = `UPDATE users SET email = ?1, first_name = ?2, last_name = ?3, email_verified = ?4, password_hash = ?5, created_at = ?6, updated_at = ?7 WHERE id = ?8 ` +
+		queryReturnUser
+
+	var e db.User
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query,
+			in.Email,
+			in.FirstName,
+			in.LastName,
+			in.EmailVerified,
+			in.PasswordHash,
+			in.CreatedAt,
+			in.UpdatedAt,
+			key,
+		)
+		return scanUser(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("User", "Store.UpdateUser", query, err)
+	}
+
+	return &e, nil
+}
+
+// UserPatch (partially) patches: User.
+type UserPatch struct {
+	Email         *string         `db:"email"`
+	FirstName     *sql.NullString `db:"first_name"`
+	LastName      *sql.NullString `db:"last_name"`
+	EmailVerified *bool           `db:"email_verified"`
+	PasswordHash  *sql.NullString `db:"password_hash"`
+	CreatedAt     *time.Time      `db:"created_at"`
+	UpdatedAt     *time.Time      `db:"updated_at"`
+}
+
+// PatchUsers updates all Users using patch that satisfy the condition of filters. The default sorting of User is used.
+// On success, it returns the updated records.
+func (s *Store) PatchUsers(ctx context.Context, p UserPatch, filters ...endo.Filter) ([]*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpUpdate, Method: "Store.PatchUsers"})
+	fieldUpdates := patchUserUpdates(p)
+	if len(fieldUpdates) < 1 {
+		return nil, endo.WrapOpError("User", "Store.PatchUsers", "", endo.ErrEmptyUpdate)
+	}
+
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`UPDATE users SET `).WriteKeyValues("%s = {}", ", ", fieldUpdates...).Write(" ")
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+	}
+	qb.Write(queryReturnUser)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("User", "Store.PatchUsers", query, err)
+	}
+
+	var c []*db.User
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanUserRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("User", "Store.PatchUsers", query, err)
+}
+
+// PatchUser updates the User with the given primary key using patch. If no User was found, endo.ErrNotFound is returned.
+// On success, it returns the updated record.
+func (s *Store) PatchUser(ctx context.Context, key int, p UserPatch) (*db.User, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpUpdate, Method: "Store.PatchUser"})
+	fieldUpdates := patchUserUpdates(p)
+	if len(fieldUpdates) < 1 {
+		return nil, endo.WrapOpError("User", "Store.PatchUser", "", endo.ErrEmptyUpdate)
+	}
+
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`UPDATE users SET `).WriteKeyValues("%s = {}", ", ", fieldUpdates...).Write(" ")
+	qb.WriteWithParams(`WHERE id = {} `, key)
+	qb.Write(queryReturnUser)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("User", "Store.PatchUser", query, err)
+	}
+
+	var e db.User
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, args...)
+		return scanUser(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("User", "Store.PatchUser", query, err)
+	}
+
+	return &e, nil
+}
+
+// patchUserUpdates returns the field updates of p, only the fields that are set are included.
+func patchUserUpdates(p UserPatch) []endo.KeyValue {
+	var fieldUpdates []endo.KeyValue
+
+	if p.Email != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `email`,
+			Value: *p.Email,
+		})
+	}
+	if p.FirstName != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `first_name`,
+			Value: *p.FirstName,
+		})
+	}
+	if p.LastName != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `last_name`,
+			Value: *p.LastName,
+		})
+	}
+	if p.EmailVerified != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `email_verified`,
+			Value: *p.EmailVerified,
+		})
+	}
+	if p.PasswordHash != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `password_hash`,
+			Value: *p.PasswordHash,
+		})
+	}
+	if p.CreatedAt != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `created_at`,
+			Value: *p.CreatedAt,
+		})
+	}
+	if p.UpdatedAt != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `updated_at`,
+			Value: *p.UpdatedAt,
+		})
+	}
+	return fieldUpdates
+}
+
+// DeleteUsers deletes all Users that satisfy the condition of filters. The default sorting of User is used.
+// On success, it returns the number of deleted records.
+func (s *Store) DeleteUsers(ctx context.Context, filters ...endo.Filter) (int64, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpDelete, Method: "Store.DeleteUsers"})
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`DELETE FROM users `)
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...)
+	}
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return 0, endo.WrapOpError("User", "Store.DeleteUsers", query, err)
+	}
+
+	var n int64
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		result, err := dbtx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err = result.RowsAffected()
+		return err
+	})
+
+	return n, endo.WrapOpError("User", "Store.DeleteUsers", query, err)
+}
+
+// DeleteUser deletes the User with the given primary key. If no User was found, endo.ErrNotFound is returned.
+func (s *Store) DeleteUser(ctx context.Context, key int) error {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpDelete, Method: "Store.DeleteUser"})
+	const query = `DELETE FROM users WHERE id = ?1`
+
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		result, err := dbtx.ExecContext(ctx, query, key)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return endo.ErrNotFound
+		}
+		return nil
+	})
+	return endo.WrapOpError("User", "Store.DeleteUser", query, err)
+}
+
+// scanUser scans a single User passed by e, using scanner s.
+// This works best if querySelectUser is used as query.
+func scanUser(e *db.User, s endo.Scanner) error {
+	return s.Scan(
+		&e.ID,
+		&e.Email,
+		&e.FirstName,
+		&e.LastName,
+		&e.DisplayName,
+		&e.EmailVerified,
+		&e.PasswordHash,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+}
+
+// scanUserRows scans all Users using scanner s, and returns the results.
+// This works best if querySelectUser is used as query.
+func scanUserRows(rows *sql.Rows) ([]*db.User, error) {
+	var c []*db.User
+	for rows.Next() {
+		var e db.User
+		if err := scanUser(&e, rows); err != nil {
+			return nil, err
+		}
+		c = append(c, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+const (
+	// querySelectRole is a prepared SQL query for selecting a Role.
+	querySelectRole = `SELECT id, name FROM roles `
+	// queryReturnRole can be used as a part of a SQL query for returning a Role.
+	queryReturnRole = ` RETURNING id, name`
+	// querySortRole is the default sorting order of Role.
+	querySortRole = ` ORDER BY id `
+	// queryFirstRole is the precompiled query for selecting the first Role without filters.
+	queryFirstRole = querySelectRole + querySortRole + "LIMIT 1"
+	// queryPageRole is the precompiled query for selecting a page of Roles without filters,
+	// its arguments are the limit and offset.
+	queryPageRole = querySelectRole + querySortRole + `LIMIT ?1 OFFSET ?2`
+)
+
+// templatePageRole is the precompiled default sorting and page bounds of Roles with filters.
+var templatePageRole = endo.NewTemplate(querySortRole + `LIMIT {} OFFSET {}`)
+
+// GetRole retrieves the Role with the given primary key. If no Role was found, endo.ErrNotFound is returned.
+func (s *Store) GetRole(ctx context.Context, key int) (*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpSelect, Method: "Store.GetRole"})
+	const query = querySelectRole + `WHERE id = ?1`
+
+	var e db.Role
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, key)
+		return scanRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("Role", "Store.GetRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// FindRole retrieves the first Role with the filters applied. The default sorting of Role is used.
+func (s *Store) FindRole(ctx context.Context, filters ...endo.Filter) (*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpSelect, Method: "Store.FindRole"})
+	query := queryFirstRole
+	var args []interface{}
+	if 0 < len(filters) {
+		qb := endo.AcquireBuilder(endo.SQLite)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectRole).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.Write(querySortRole + "LIMIT 1")
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("Role", "Store.FindRole", query, err)
+		}
+	}
+
+	var e db.Role
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, args...)
+		return scanRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("Role", "Store.FindRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// GetRoles retrieves all Roles with the filters applied, within the bounds of the page.
+// The default sorting of Role is used.
+func (s *Store) GetRoles(ctx context.Context, po endo.PageOptions, filters ...endo.Filter) ([]*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpSelect, Method: "Store.GetRoles"})
+	limit, offset := po.Args()
+	query, args := queryPageRole, []interface{}{limit, offset}
+	if 0 < len(filters) {
+		qb := endo.AcquireBuilder(endo.SQLite)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectRole).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.WriteTemplate(templatePageRole, limit, offset)
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("Role", "Store.GetRoles", query, err)
+		}
+	}
+
+	var c []*db.Role
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanRoleRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("Role", "Store.GetRoles", query, err)
+}
+
+// CreateRole inserts a Role record. On success, it returns the created record.
+func (s *Store) CreateRole(ctx context.Context, in db.Role) (*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpInsert, Method: "Store.CreateRole"})
+	const query = `INSERT INTO roles (name) VALUES (?1) ` +
+		queryReturnRole
+
+	var e db.Role
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query,
+			in.Name,
+		)
+		return scanRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("Role", "Store.CreateRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// UpdateRoles updates all Roles that satisfy the condition of filters. The default sorting of Role is used.
+// On success, it returns the updated records.
+func (s *Store) UpdateRoles(ctx context.Context, in db.Role, filters ...endo.Filter) ([]*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpUpdate, Method: "Store.UpdateRoles"})
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.WriteWithArgs(`UPDATE roles SET name = ?1 `,
+		in.Name,
+	)
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+	}
+	qb.Write(queryReturnRole)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("Role", "Store.UpdateRoles", query, err)
+	}
+
+	var c []*db.Role
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanRoleRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("Role", "Store.UpdateRoles", query, err)
+}
+
+// UpdateRole updates the Role with the given primary key. If no Role was found, endo.ErrNotFound is returned.
+// On success, it returns the updated record.
+func (s *Store) UpdateRole(ctx context.Context, key int, in db.Role) (*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpUpdate, Method: "Store.UpdateRole"})
+	const query = `UPDATE roles SET name = ?1 WHERE id = ?2 ` +
+		queryReturnRole
+
+	var e db.Role
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query,
+			in.Name,
+			key,
+		)
+		return scanRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("Role", "Store.UpdateRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// RolePatch (partially) patches: Role.
+type RolePatch struct {
+	Name *string `db:"name"`
+}
+
+// PatchRoles updates all Roles using patch that satisfy the condition of filters. The default sorting of Role is used.
+// On success, it returns the updated records.
+func (s *Store) PatchRoles(ctx context.Context, p RolePatch, filters ...endo.Filter) ([]*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpUpdate, Method: "Store.PatchRoles"})
+	fieldUpdates := patchRoleUpdates(p)
+	if len(fieldUpdates) < 1 {
+		return nil, endo.WrapOpError("Role", "Store.PatchRoles", "", endo.ErrEmptyUpdate)
+	}
+
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`UPDATE roles SET `).WriteKeyValues("%s = {}", ", ", fieldUpdates...).Write(" ")
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+	}
+	qb.Write(queryReturnRole)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("Role", "Store.PatchRoles", query, err)
+	}
+
+	var c []*db.Role
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanRoleRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("Role", "Store.PatchRoles", query, err)
+}
+
+// PatchRole updates the Role with the given primary key using patch. If no Role was found, endo.ErrNotFound is returned.
+// On success, it returns the updated record.
+func (s *Store) PatchRole(ctx context.Context, key int, p RolePatch) (*db.Role, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpUpdate, Method: "Store.PatchRole"})
+	fieldUpdates := patchRoleUpdates(p)
+	if len(fieldUpdates) < 1 {
+		return nil, endo.WrapOpError("Role", "Store.PatchRole", "", endo.ErrEmptyUpdate)
+	}
+
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`UPDATE roles SET `).WriteKeyValues("%s = {}", ", ", fieldUpdates...).Write(" ")
+	qb.WriteWithParams(`WHERE id = {} `, key)
+	qb.Write(queryReturnRole)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("Role", "Store.PatchRole", query, err)
+	}
+
+	var e db.Role
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, args...)
+		return scanRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("Role", "Store.PatchRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// patchRoleUpdates returns the field updates of p, only the fields that are set are included.
+func patchRoleUpdates(p RolePatch) []endo.KeyValue {
+	var fieldUpdates []endo.KeyValue
+
+	if p.Name != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `name`,
+			Value: *p.Name,
+		})
+	}
+	return fieldUpdates
+}
+
+// DeleteRoles deletes all Roles that satisfy the condition of filters. The default sorting of Role is used.
+// On success, it returns the number of deleted records.
+func (s *Store) DeleteRoles(ctx context.Context, filters ...endo.Filter) (int64, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpDelete, Method: "Store.DeleteRoles"})
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`DELETE FROM roles `)
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...)
+	}
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return 0, endo.WrapOpError("Role", "Store.DeleteRoles", query, err)
+	}
+
+	var n int64
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		result, err := dbtx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err = result.RowsAffected()
+		return err
+	})
+
+	return n, endo.WrapOpError("Role", "Store.DeleteRoles", query, err)
+}
+
+// DeleteRole deletes the Role with the given primary key. If no Role was found, endo.ErrNotFound is returned.
+func (s *Store) DeleteRole(ctx context.Context, key int) error {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpDelete, Method: "Store.DeleteRole"})
+	const query = `DELETE FROM roles WHERE id = ?1`
+
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		result, err := dbtx.ExecContext(ctx, query, key)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return endo.ErrNotFound
+		}
+		return nil
+	})
+	return endo.WrapOpError("Role", "Store.DeleteRole", query, err)
+}
+
+// scanRole scans a single Role passed by e, using scanner s.
+// This works best if querySelectRole is used as query.
+func scanRole(e *db.Role, s endo.Scanner) error {
+	return s.Scan(
+		&e.ID,
+		&e.Name,
+	)
+}
+
+// scanRoleRows scans all Roles using scanner s, and returns the results.
+// This works best if querySelectRole is used as query.
+func scanRoleRows(rows *sql.Rows) ([]*db.Role, error) {
+	var c []*db.Role
+	for rows.Next() {
+		var e db.Role
+		if err := scanRole(&e, rows); err != nil {
+			return nil, err
+		}
+		c = append(c, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+const (
+	// querySelectUserRole is a prepared SQL query for selecting a UserRole.
+	querySelectUserRole = `SELECT user_id, role_id FROM user_roles `
+	// queryReturnUserRole can be used as a part of a SQL query for returning a UserRole.
+	queryReturnUserRole = ` RETURNING user_id, role_id`
+	// querySortUserRole is the default sorting order of UserRole.
+	querySortUserRole = ` ORDER BY user_id, role_id `
+	// queryFirstUserRole is the precompiled query for selecting the first UserRole without filters.
+	queryFirstUserRole = querySelectUserRole + querySortUserRole + "LIMIT 1"
+	// queryPageUserRole is the precompiled query for selecting a page of UserRoles without filters,
+	// its arguments are the limit and offset.
+	queryPageUserRole = querySelectUserRole + querySortUserRole + `LIMIT ?1 OFFSET ?2`
+)
+
+// templatePageUserRole is the precompiled default sorting and page bounds of UserRoles with filters.
+var templatePageUserRole = endo.NewTemplate(querySortUserRole + `LIMIT {} OFFSET {}`)
+
+// UserRoleKey is the primary key of UserRole.
+type UserRoleKey struct {
+	UserID int `db:"user_id"`
+	RoleID int `db:"role_id"`
+}
+
+// GetUserRole retrieves the UserRole with the given primary key. If no UserRole was found, endo.ErrNotFound is returned.
+func (s *Store) GetUserRole(ctx context.Context, key UserRoleKey) (*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpSelect, Method: "Store.GetUserRole"})
+	const query = querySelectUserRole + `WHERE user_id = ?1 AND role_id = ?2`
+
+	var e db.UserRole
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, key.UserID, key.RoleID)
+		return scanUserRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.GetUserRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// FindUserRole retrieves the first UserRole with the filters applied. The default sorting of UserRole is used.
+func (s *Store) FindUserRole(ctx context.Context, filters ...endo.Filter) (*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpSelect, Method: "Store.FindUserRole"})
+	query := queryFirstUserRole
+	var args []interface{}
+	if 0 < len(filters) {
+		qb := endo.AcquireBuilder(endo.SQLite)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectUserRole).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.Write(querySortUserRole + "LIMIT 1")
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("UserRole", "Store.FindUserRole", query, err)
+		}
+	}
+
+	var e db.UserRole
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, args...)
+		return scanUserRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.FindUserRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// GetUserRoles retrieves all UserRoles with the filters applied, within the bounds of the page.
+// The default sorting of UserRole is used.
+func (s *Store) GetUserRoles(ctx context.Context, po endo.PageOptions, filters ...endo.Filter) ([]*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpSelect, Method: "Store.GetUserRoles"})
+	limit, offset := po.Args()
+	query, args := queryPageUserRole, []interface{}{limit, offset}
+	if 0 < len(filters) {
+		qb := endo.AcquireBuilder(endo.SQLite)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectUserRole).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.WriteTemplate(templatePageUserRole, limit, offset)
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("UserRole", "Store.GetUserRoles", query, err)
+		}
+	}
+
+	var c []*db.UserRole
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanUserRoleRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("UserRole", "Store.GetUserRoles", query, err)
+}
+
+// CreateUserRole inserts a UserRole record. On success, it returns the created record.
+func (s *Store) CreateUserRole(ctx context.Context, in db.UserRole) (*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpInsert, Method: "Store.CreateUserRole"})
+	const query = `INSERT INTO user_roles (user_id, role_id) VALUES (?1, ?2) ` +
+		queryReturnUserRole
+
+	var e db.UserRole
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query,
+			in.UserID,
+			in.RoleID,
+		)
+		return scanUserRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.CreateUserRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// UpdateUserRoles updates all UserRoles that satisfy the condition of filters. The default sorting of UserRole is used.
+// On success, it returns the updated records.
+func (s *Store) UpdateUserRoles(ctx context.Context, in db.UserRole, filters ...endo.Filter) ([]*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpUpdate, Method: "Store.UpdateUserRoles"})
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.WriteWithArgs(`UPDATE user_roles SET user_id = ?1, role_id = ?2 `,
+		in.UserID,
+		in.RoleID,
+	)
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+	}
+	qb.Write(queryReturnUserRole)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.UpdateUserRoles", query, err)
+	}
+
+	var c []*db.UserRole
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanUserRoleRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("UserRole", "Store.UpdateUserRoles", query, err)
+}
+
+// UpdateUserRole updates the UserRole with the given primary key. If no UserRole was found, endo.ErrNotFound is returned.
+// On success, it returns the updated record.
+func (s *Store) UpdateUserRole(ctx context.Context, key UserRoleKey, in db.UserRole) (*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpUpdate, Method: "Store.UpdateUserRole"})
+	const query = `UPDATE user_roles SET user_id = ?1, role_id = ?2 WHERE user_id = ?3 AND role_id = ?4 ` +
+		queryReturnUserRole
+
+	var e db.UserRole
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query,
+			in.UserID,
+			in.RoleID,
+			key.UserID,
+			key.RoleID,
+		)
+		return scanUserRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.UpdateUserRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// UserRolePatch (partially) patches: UserRole.
+type UserRolePatch struct {
+	UserID *int `db:"user_id"`
+	RoleID *int `db:"role_id"`
+}
+
+// PatchUserRoles updates all UserRoles using patch that satisfy the condition of filters. The default sorting of UserRole is used.
+// On success, it returns the updated records.
+func (s *Store) PatchUserRoles(ctx context.Context, p UserRolePatch, filters ...endo.Filter) ([]*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpUpdate, Method: "Store.PatchUserRoles"})
+	fieldUpdates := patchUserRoleUpdates(p)
+	if len(fieldUpdates) < 1 {
+		return nil, endo.WrapOpError("UserRole", "Store.PatchUserRoles", "", endo.ErrEmptyUpdate)
+	}
+
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`UPDATE user_roles SET `).WriteKeyValues("%s = {}", ", ", fieldUpdates...).Write(" ")
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+	}
+	qb.Write(queryReturnUserRole)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.PatchUserRoles", query, err)
+	}
+
+	var c []*db.UserRole
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanUserRoleRows(rows)
+		return err
+	})
+
+	return c, endo.WrapOpError("UserRole", "Store.PatchUserRoles", query, err)
+}
+
+// PatchUserRole updates the UserRole with the given primary key using patch. If no UserRole was found, endo.ErrNotFound is returned.
+// On success, it returns the updated record.
+func (s *Store) PatchUserRole(ctx context.Context, key UserRoleKey, p UserRolePatch) (*db.UserRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpUpdate, Method: "Store.PatchUserRole"})
+	fieldUpdates := patchUserRoleUpdates(p)
+	if len(fieldUpdates) < 1 {
+		return nil, endo.WrapOpError("UserRole", "Store.PatchUserRole", "", endo.ErrEmptyUpdate)
+	}
+
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`UPDATE user_roles SET `).WriteKeyValues("%s = {}", ", ", fieldUpdates...).Write(" ")
+	qb.WriteWithParams(`WHERE user_id = {} AND role_id = {} `, key.UserID, key.RoleID)
+	qb.Write(queryReturnUserRole)
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.PatchUserRole", query, err)
+	}
+
+	var e db.UserRole
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, args...)
+		return scanUserRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("UserRole", "Store.PatchUserRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// patchUserRoleUpdates returns the field updates of p, only the fields that are set are included.
+func patchUserRoleUpdates(p UserRolePatch) []endo.KeyValue {
+	var fieldUpdates []endo.KeyValue
+
+	if p.UserID != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `user_id`,
+			Value: *p.UserID,
+		})
+	}
+	if p.RoleID != nil {
+		fieldUpdates = append(fieldUpdates, endo.KeyValue{
+			Key:   `role_id`,
+			Value: *p.RoleID,
+		})
+	}
+	return fieldUpdates
+}
+
+// DeleteUserRoles deletes all UserRoles that satisfy the condition of filters. The default sorting of UserRole is used.
+// On success, it returns the number of deleted records.
+func (s *Store) DeleteUserRoles(ctx context.Context, filters ...endo.Filter) (int64, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpDelete, Method: "Store.DeleteUserRoles"})
+	qb := endo.AcquireBuilder(endo.SQLite)
+	defer endo.ReleaseBuilder(qb)
+	qb.Write(`DELETE FROM user_roles `)
+	if 0 < len(filters) {
+		qb.Write("WHERE ").WriteFilters(filters...)
+	}
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return 0, endo.WrapOpError("UserRole", "Store.DeleteUserRoles", query, err)
+	}
+
+	var n int64
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		result, err := dbtx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err = result.RowsAffected()
+		return err
+	})
+
+	return n, endo.WrapOpError("UserRole", "Store.DeleteUserRoles", query, err)
+}
+
+// DeleteUserRole deletes the UserRole with the given primary key. If no UserRole was found, endo.ErrNotFound is returned.
+func (s *Store) DeleteUserRole(ctx context.Context, key UserRoleKey) error {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "UserRole", Table: "user_roles", Operation: endo.OpDelete, Method: "Store.DeleteUserRole"})
+	const query = `DELETE FROM user_roles WHERE user_id = ?1 AND role_id = ?2`
+
+	err := s.TX(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		result, err := dbtx.ExecContext(ctx, query, key.UserID, key.RoleID)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return endo.ErrNotFound
+		}
+		return nil
+	})
+	return endo.WrapOpError("UserRole", "Store.DeleteUserRole", query, err)
+}
+
+// scanUserRole scans a single UserRole passed by e, using scanner s.
+// This works best if querySelectUserRole is used as query.
+func scanUserRole(e *db.UserRole, s endo.Scanner) error {
+	return s.Scan(
+		&e.UserID,
+		&e.RoleID,
+	)
+}
+
+// scanUserRoleRows scans all UserRoles using scanner s, and returns the results.
+// This works best if querySelectUserRole is used as query.
+func scanUserRoleRows(rows *sql.Rows) ([]*db.UserRole, error) {
+	var c []*db.UserRole
+	for rows.Next() {
+		var e db.UserRole
+		if err := scanUserRole(&e, rows); err != nil {
+			return nil, err
+		}
+		c = append(c, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,319 @@
+package sqlitestore_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/semrekkers/endo/examples/db"
+	"github.com/semrekkers/endo/examples/db/sqlitestore"
+	"github.com/semrekkers/endo/pkg/endo"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schema = `
+CREATE TABLE users (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	email          TEXT NOT NULL UNIQUE,
+	first_name     TEXT,
+	last_name      TEXT,
+	display_name   TEXT GENERATED ALWAYS AS (first_name || ' ' || last_name),
+	email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+	password_hash  TEXT,
+	created_at     TIMESTAMP NOT NULL,
+	updated_at     TIMESTAMP NOT NULL
+);
+
+CREATE TABLE roles (
+	id   INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE user_roles (
+	user_id INTEGER NOT NULL REFERENCES users (id),
+	role_id INTEGER NOT NULL REFERENCES roles (id),
+	PRIMARY KEY (user_id, role_id)
+);
+
+CREATE VIEW effective_roles AS
+	SELECT user_roles.user_id, user_roles.role_id, roles.name AS role_name
+	FROM user_roles JOIN roles ON roles.id = user_roles.role_id;
+`
+
+var testTime = time.Date(2022, 1, 29, 15, 4, 5, 0, time.UTC)
+
+func newStore(t *testing.T) *sqlitestore.Store {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	// Every connection opens a new in-memory database, so use only one.
+	sqlDB.SetMaxOpenConns(1)
+	_, err = sqlDB.Exec(schema)
+	require.NoError(t, err)
+
+	return &sqlitestore.Store{TX: endo.UseDB(sqlDB)}
+}
+
+func createUser(t *testing.T, s *sqlitestore.Store, email string) *db.User {
+	u, err := s.CreateUser(context.Background(), db.User{
+		Email:     email,
+		FirstName: sql.NullString{String: "Jane", Valid: true},
+		LastName:  sql.NullString{String: "Doe", Valid: true},
+		CreatedAt: testTime,
+		UpdatedAt: testTime,
+	})
+	require.NoError(t, err)
+	return u
+}
+
+func TestCreateAndGetUser(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	created := createUser(t, s, "jane@example.com")
+	assert.NotZero(t, created.ID)
+	assert.Equal(t, "jane@example.com", created.Email)
+	assert.Equal(t, sql.NullString{String: "Jane Doe", Valid: true}, created.DisplayName)
+	assert.True(t, testTime.Equal(created.CreatedAt))
+
+	u, err := s.GetUser(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, u)
+
+	_, err = s.GetUser(ctx, created.ID+1)
+	assert.ErrorIs(t, err, endo.ErrNotFound)
+	assert.EqualError(t, err, "Store.GetUser: sql: no rows in result set")
+}
+
+func TestFindAndGetUsers(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	createUser(t, s, "a@example.com")
+	b := createUser(t, s, "b@example.com")
+	createUser(t, s, "c@example.com")
+
+	u, err := s.FindUser(ctx, endo.Eq("email", "b@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, b, u)
+
+	u, err = s.FindUser(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", u.Email)
+
+	c, err := s.GetUsers(ctx, endo.PageOptions{Page: 1, PerPage: 2})
+	require.NoError(t, err)
+	require.Len(t, c, 2)
+	assert.Equal(t, "a@example.com", c[0].Email)
+	assert.Equal(t, "b@example.com", c[1].Email)
+
+	c, err = s.GetUsers(ctx, endo.PageOptions{Page: 2, PerPage: 2})
+	require.NoError(t, err)
+	require.Len(t, c, 1)
+	assert.Equal(t, "c@example.com", c[0].Email)
+
+	c, err = s.GetUsers(ctx, endo.PageOptions{PerPage: 10}, endo.In("email", []string{"a@example.com", "c@example.com"}))
+	require.NoError(t, err)
+	require.Len(t, c, 2)
+	assert.Equal(t, "a@example.com", c[0].Email)
+	assert.Equal(t, "c@example.com", c[1].Email)
+
+	c, err = s.GetUsers(ctx, endo.PageOptions{PerPage: 10}, endo.In("id", []int64{}))
+	require.NoError(t, err)
+	assert.Empty(t, c)
+}
+
+func TestUpdateUser(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	created := createUser(t, s, "jane@example.com")
+	in := *created
+	in.Email = "jane.doe@example.com"
+	in.EmailVerified = true
+
+	u, err := s.UpdateUser(ctx, created.ID, in)
+	require.NoError(t, err)
+	assert.Equal(t, "jane.doe@example.com", u.Email)
+	assert.True(t, u.EmailVerified)
+
+	_, err = s.UpdateUser(ctx, created.ID+1, in)
+	assert.ErrorIs(t, err, endo.ErrNotFound)
+}
+
+func TestPatchUsers(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	a := createUser(t, s, "a@example.com")
+	createUser(t, s, "b@example.com")
+	lastName := sql.NullString{String: "Smith", Valid: true}
+
+	u, err := s.PatchUser(ctx, a.ID, sqlitestore.UserPatch{LastName: &lastName})
+	require.NoError(t, err)
+	assert.Equal(t, sql.NullString{String: "Jane Smith", Valid: true}, u.DisplayName)
+
+	verified := true
+	c, err := s.PatchUsers(ctx, sqlitestore.UserPatch{EmailVerified: &verified})
+	require.NoError(t, err)
+	require.Len(t, c, 2)
+	for _, u := range c {
+		assert.True(t, u.EmailVerified)
+	}
+
+	_, err = s.PatchUsers(ctx, sqlitestore.UserPatch{})
+	assert.ErrorIs(t, err, endo.ErrEmptyUpdate)
+}
+
+func TestDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	a := createUser(t, s, "a@example.com")
+	createUser(t, s, "b@example.com")
+	createUser(t, s, "c@example.com")
+
+	require.NoError(t, s.DeleteUser(ctx, a.ID))
+	assert.ErrorIs(t, s.DeleteUser(ctx, a.ID), endo.ErrNotFound)
+
+	n, err := s.DeleteUsers(ctx, endo.Or(endo.Eq("email", "b@example.com"), endo.IsNull("email")))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	c, err := s.GetUsers(ctx, endo.PageOptions{PerPage: 10})
+	require.NoError(t, err)
+	require.Len(t, c, 1)
+	assert.Equal(t, "c@example.com", c[0].Email)
+}
+
+func TestCompositeKey(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	u := createUser(t, s, "jane@example.com")
+	admin, err := s.CreateRole(ctx, db.Role{Name: "admin"})
+	require.NoError(t, err)
+	editor, err := s.CreateRole(ctx, db.Role{Name: "editor"})
+	require.NoError(t, err)
+
+	_, err = s.CreateUserRole(ctx, db.UserRole{UserID: u.ID, RoleID: admin.ID})
+	require.NoError(t, err)
+
+	key := sqlitestore.UserRoleKey{UserID: u.ID, RoleID: admin.ID}
+	ur, err := s.GetUserRole(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, &db.UserRole{UserID: u.ID, RoleID: admin.ID}, ur)
+
+	ur, err = s.PatchUserRole(ctx, key, sqlitestore.UserRolePatch{RoleID: &editor.ID})
+	require.NoError(t, err)
+	assert.Equal(t, editor.ID, ur.RoleID)
+
+	_, err = s.GetUserRole(ctx, key)
+	assert.ErrorIs(t, err, endo.ErrNotFound)
+
+	er, err := s.GetEffectiveRole(ctx, sqlitestore.EffectiveRoleKey{UserID: u.ID, RoleID: editor.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "editor", er.RoleName)
+
+	require.NoError(t, s.DeleteUserRole(ctx, sqlitestore.UserRoleKey{UserID: u.ID, RoleID: editor.ID}))
+	_, err = s.GetEffectiveRole(ctx, sqlitestore.EffectiveRoleKey{UserID: u.ID, RoleID: editor.ID})
+	assert.ErrorIs(t, err, endo.ErrNotFound)
+}
+
+func TestConstraintViolation(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	u := createUser(t, s, "jane@example.com")
+	_, err := s.CreateUser(ctx, db.User{Email: "jane@example.com", CreatedAt: testTime, UpdatedAt: testTime})
+	require.ErrorIs(t, err, endo.ErrUniqueViolation)
+	var opErr *endo.OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, "User", opErr.Model)
+	assert.Equal(t, "Store.CreateUser", opErr.Op)
+	assert.Contains(t, opErr.Query, "INSERT INTO users")
+	var dbErr *endo.DBError
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "users", dbErr.Table)
+	assert.Equal(t, "email", dbErr.Column)
+
+	role, err := s.CreateRole(ctx, db.Role{Name: "admin"})
+	require.NoError(t, err)
+	_, err = s.CreateUserRole(ctx, db.UserRole{UserID: u.ID, RoleID: role.ID})
+	require.NoError(t, err)
+	_, err = s.CreateUserRole(ctx, db.UserRole{UserID: u.ID, RoleID: role.ID})
+	require.ErrorIs(t, err, endo.ErrUniqueViolation)
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "user_roles", dbErr.Table)
+	assert.Empty(t, dbErr.Column)
+}
+
+// opInfoHook records the OpInfo of every query.
+type opInfoHook struct {
+	ops []endo.OpInfo
+}
+
+func (h *opInfoHook) BeforeQuery(ctx context.Context, q *endo.QueryInfo) context.Context {
+	info, _ := endo.OpInfoFromContext(ctx)
+	h.ops = append(h.ops, info)
+	return ctx
+}
+
+func (h *opInfoHook) AfterQuery(ctx context.Context, q *endo.QueryInfo) {}
+
+func TestOpInfo(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+	h := &opInfoHook{}
+	s.TX = endo.WithHooks(s.TX, h)
+
+	u := createUser(t, s, "jane@example.com")
+	verified := true
+	_, err := s.PatchUser(ctx, u.ID, sqlitestore.UserPatch{EmailVerified: &verified})
+	require.NoError(t, err)
+	_, err = s.GetUsers(ctx, endo.PageOptions{PerPage: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, []endo.OpInfo{
+		{Model: "User", Table: "users", Operation: endo.OpInsert, Method: "Store.CreateUser"},
+		{Model: "User", Table: "users", Operation: endo.OpUpdate, Method: "Store.PatchUser"},
+		{Model: "User", Table: "users", Operation: endo.OpSelect, Method: "Store.GetUsers"},
+	}, h.ops)
+}
+
+func benchmarkGetUser(b *testing.B, tx func(*sql.DB) endo.TxFunc) {
+	ctx := context.Background()
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(b, err)
+	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(1)
+	_, err = sqlDB.Exec(schema)
+	require.NoError(b, err)
+	s := &sqlitestore.Store{TX: tx(sqlDB)}
+	u, err := s.CreateUser(ctx, db.User{Email: "jane@example.com", CreatedAt: testTime, UpdatedAt: testTime})
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GetUser(ctx, u.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetUser(b *testing.B) {
+	benchmarkGetUser(b, func(sqlDB *sql.DB) endo.TxFunc {
+		return endo.UseDB(sqlDB)
+	})
+}
+
+func BenchmarkGetUserCachedStmts(b *testing.B) {
+	benchmarkGetUser(b, func(sqlDB *sql.DB) endo.TxFunc {
+		return endo.UseDB(sqlDB, endo.Stmts(endo.CachedStmts(sqlDB)))
+	})
+}
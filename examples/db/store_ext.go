@@ -26,14 +26,14 @@ func (s *Store) ExpandRolesInUser(ctx context.Context, u *User) error {
 }
 
 // GetExpandedUsers returns fully expanded User entities, filters can optionally be applied.
-func (s *Store) GetExpandedUsers(ctx context.Context, po endo.PageOptions, filters ...endo.KeyValue) ([]*User, error) {
+func (s *Store) GetExpandedUsers(ctx context.Context, po endo.PageOptions, filters ...endo.Filter) ([]*User, error) {
 	var c []*User
 
 	// Use existing Store methods inside one transaction, note the endo.TxMulti flag.
 	err := s.TX(ctx, endo.TxMulti|endo.TxReadOnly, func(dbtx endo.DBTX) error {
 		var err error
 
-		// Create the transactional Store (txs).
+		// Create the transactional Store (txs), its methods using endo.TxMulti are nested inside a savepoint.
 		txs := Store{endo.WrapTX(dbtx)}
 
 		c, err = txs.GetUsers(ctx, po, filters...)
@@ -55,3 +55,39 @@ func (s *Store) GetExpandedUsers(ctx context.Context, po endo.PageOptions, filte
 
 	return c, nil
 }
+
+// GetUsersWithRole retrieves all Users which have the role with name, filters can optionally be applied
+// to the roles.
+func (s *Store) GetUsersWithRole(ctx context.Context, po endo.PageOptions, name string, filters ...endo.Filter) ([]*User, error) {
+	// The subquery selects the user IDs, it has its own parameters.
+	var sub endo.Builder
+	sub.Write("SELECT user_roles.user_id FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE ").
+		WriteFilters(endo.Eq("roles.name", name), endo.And(filters...))
+
+	// WriteBuilder renumbers the parameters of the subquery.
+	var qb endo.Builder
+	qb.Write(querySelectUser).
+		Write("WHERE id IN (").WriteBuilder(&sub).Write(")").
+		Write(querySortUser).
+		WriteLimitOffset(po.Args())
+	query, args := qb.Build()
+	if err := qb.Err(); err != nil {
+		return nil, err
+	}
+
+	var c []*User
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		rows, err := dbtx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		c, err = scanUserRows(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -11,22 +11,59 @@ import (
 
 const (
 	// querySelectEffectiveRole is a prepared SQL query for selecting a EffectiveRole.
-	querySelectEffectiveRole = `SELECT role_id, role_name FROM effective_roles `
+	querySelectEffectiveRole = `SELECT user_id, role_id, role_name FROM effective_roles `
 	// queryReturnEffectiveRole can be used as a part of a SQL query for returning a EffectiveRole.
-	queryReturnEffectiveRole = ` RETURNING role_id, role_name`
+	queryReturnEffectiveRole = ` RETURNING user_id, role_id, role_name`
 	// querySortEffectiveRole is the default sorting order of EffectiveRole.
 	querySortEffectiveRole = ` ORDER BY user_id, role_id `
+	// queryFirstEffectiveRole is the precompiled query for selecting the first EffectiveRole without filters.
+	queryFirstEffectiveRole = querySelectEffectiveRole + querySortEffectiveRole + "LIMIT 1"
+	// queryPageEffectiveRole is the precompiled query for selecting a page of EffectiveRoles without filters,
+	// its arguments are the limit and offset.
+	queryPageEffectiveRole = querySelectEffectiveRole + querySortEffectiveRole + `LIMIT $1 OFFSET $2`
 )
 
-// GetEffectiveRole retrieves the first EffectiveRole with the filters applied. The default sorting of EffectiveRole is used.
-func (s *Store) GetEffectiveRole(ctx context.Context, filters ...endo.KeyValue) (*EffectiveRole, error) {
-	var qb endo.Builder
-	qb.Write(querySelectEffectiveRole)
+// templatePageEffectiveRole is the precompiled default sorting and page bounds of EffectiveRoles with filters.
+var templatePageEffectiveRole = endo.NewTemplate(querySortEffectiveRole + `LIMIT {} OFFSET {}`)
+
+// EffectiveRoleKey is the primary key of EffectiveRole.
+type EffectiveRoleKey struct {
+	UserID int `db:"user_id"`
+	RoleID int `db:"role_id"`
+}
+
+// GetEffectiveRole retrieves the EffectiveRole with the given primary key. If no EffectiveRole was found, endo.ErrNotFound is returned.
+func (s *Store) GetEffectiveRole(ctx context.Context, key EffectiveRoleKey) (*EffectiveRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "EffectiveRole", Table: "effective_roles", Operation: endo.OpSelect, Method: "Store.GetEffectiveRole"})
+	const query = querySelectEffectiveRole + `WHERE user_id = $1 AND role_id = $2`
+
+	var e EffectiveRole
+	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
+		row := dbtx.QueryRowContext(ctx, query, key.UserID, key.RoleID)
+		return scanEffectiveRole(&e, row)
+	})
+	if err != nil {
+		return nil, endo.WrapOpError("EffectiveRole", "Store.GetEffectiveRole", query, err)
+	}
+
+	return &e, nil
+}
+
+// FindEffectiveRole retrieves the first EffectiveRole with the filters applied. The default sorting of EffectiveRole is used.
+func (s *Store) FindEffectiveRole(ctx context.Context, filters ...endo.Filter) (*EffectiveRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "EffectiveRole", Table: "effective_roles", Operation: endo.OpSelect, Method: "Store.FindEffectiveRole"})
+	query := queryFirstEffectiveRole
+	var args []interface{}
 	if 0 < len(filters) {
-		qb.Write("WHERE ").WriteKeyValues("(%s)", " AND ", filters...).Write(" ")
+		qb := endo.AcquireBuilder(endo.Postgres)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectEffectiveRole).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.Write(querySortEffectiveRole + "LIMIT 1")
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("EffectiveRole", "Store.FindEffectiveRole", query, err)
+		}
 	}
-	qb.Write(querySortEffectiveRole + "LIMIT 1")
-	query, args := qb.Build()
 
 	var e EffectiveRole
 	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
@@ -34,7 +71,7 @@ func (s *Store) GetEffectiveRole(ctx context.Context, filters ...endo.KeyValue)
 		return scanEffectiveRole(&e, row)
 	})
 	if err != nil {
-		return nil, err
+		return nil, endo.WrapOpError("EffectiveRole", "Store.FindEffectiveRole", query, err)
 	}
 
 	return &e, nil
@@ -42,16 +79,20 @@ func (s *Store) GetEffectiveRole(ctx context.Context, filters ...endo.KeyValue)
 
 // GetEffectiveRoles retrieves all EffectiveRoles with the filters applied, within the bounds of the page.
 // The default sorting of EffectiveRole is used.
-func (s *Store) GetEffectiveRoles(ctx context.Context, po endo.PageOptions, filters ...endo.KeyValue) ([]*EffectiveRole, error) {
-	var qb endo.Builder
-	qb.Write(querySelectEffectiveRole)
+func (s *Store) GetEffectiveRoles(ctx context.Context, po endo.PageOptions, filters ...endo.Filter) ([]*EffectiveRole, error) {
+	ctx = endo.WithOpInfo(ctx, endo.OpInfo{Model: "EffectiveRole", Table: "effective_roles", Operation: endo.OpSelect, Method: "Store.GetEffectiveRoles"})
+	limit, offset := po.Args()
+	query, args := queryPageEffectiveRole, []interface{}{limit, offset}
 	if 0 < len(filters) {
-		qb.Write("WHERE ").WriteKeyValues("(%s)", " AND ", filters...).Write(" ")
+		qb := endo.AcquireBuilder(endo.Postgres)
+		defer endo.ReleaseBuilder(qb)
+		qb.Write(querySelectEffectiveRole).Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.WriteTemplate(templatePageEffectiveRole, limit, offset)
+		query, args = qb.Build()
+		if err := qb.Err(); err != nil {
+			return nil, endo.WrapOpError("EffectiveRole", "Store.GetEffectiveRoles", query, err)
+		}
 	}
-	qb.Write(querySortEffectiveRole)
-	limit, offset := po.Args()
-	qb.WriteWithParams("LIMIT {} OFFSET {}", limit, offset)
-	query, args := qb.Build()
 
 	var c []*EffectiveRole
 	err := s.TX(ctx, endo.TxReadOnly, func(dbtx endo.DBTX) error {
@@ -64,13 +105,14 @@ func (s *Store) GetEffectiveRoles(ctx context.Context, po endo.PageOptions, filt
 		return err
 	})
 
-	return c, err
+	return c, endo.WrapOpError("EffectiveRole", "Store.GetEffectiveRoles", query, err)
 }
 
 // scanEffectiveRole scans a single EffectiveRole passed by e, using scanner s.
 // This works best if querySelectEffectiveRole is used as query.
 func scanEffectiveRole(e *EffectiveRole, s endo.Scanner) error {
 	return s.Scan(
+		&e.UserID,
 		&e.RoleID,
 		&e.RoleName,
 	)
@@ -87,5 +129,8 @@ func scanEffectiveRoleRows(rows *sql.Rows) ([]*EffectiveRole, error) {
 		}
 		c = append(c, &e)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
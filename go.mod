@@ -3,6 +3,7 @@ module github.com/semrekkers/endo
 go 1.17
 
 require (
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/tools v0.1.9
 )
@@ -1,20 +1,85 @@
 package endo
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+)
+
+var (
+	// ErrTooFewArgs is returned when a query has more parameters than arguments.
+	ErrTooFewArgs = errors.New("too few arguments for the query parameters")
+	// ErrTooManyArgs is returned when a query has less parameters than arguments.
+	ErrTooManyArgs = errors.New("too many arguments for the query parameters")
+	// ErrEmptySlice is returned when a "{...}" parameter gets an empty slice as argument.
+	ErrEmptySlice = errors.New("empty slice for the {...} parameter")
 )
 
 // A Builder is used to build a query string using Write methods. The zero value is ready to use. Do not copy a
 // non-zero Builder, use Copy() instead.
 type Builder struct {
+	// Dialect is the SQL dialect to build the query for.
+	// If Dialect is nil, Builder uses endo.Postgres.
+	Dialect *Dialect
 	// FormatParam formats a parameter with index i.
-	// If FormatParam is nil, Builder uses endo.FixedParam.
+	// If FormatParam is nil, Builder uses Dialect.FormatParam.
 	FormatParam func(b *Builder, i int)
 
-	s    strings.Builder
-	args []interface{}
+	s      buffer
+	args   []interface{}
+	params []param
+	err    error
+}
+
+// buffer is the query buffer of a Builder. Unlike a strings.Builder, its storage is kept when
+// it's reset, so a pooled Builder doesn't allocate it again.
+type buffer []byte
+
+func (b *buffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+func (b *buffer) WriteString(s string) (int, error) {
+	*b = append(*b, s...)
+	return len(s), nil
+}
+
+func (b *buffer) WriteByte(c byte) error {
+	*b = append(*b, c)
+	return nil
+}
+
+// Grow grows the capacity of the buffer, if needed, to fit another n bytes.
+func (b *buffer) Grow(n int) {
+	if cap(*b)-len(*b) < n {
+		buf := make([]byte, len(*b), 2*cap(*b)+n)
+		copy(buf, *b)
+		*b = buf
+	}
+}
+
+func (b *buffer) Len() int {
+	return len(*b)
+}
+
+// String returns a copy of the contents of the buffer.
+func (b *buffer) String() string {
+	return string(*b)
+}
+
+// Reset empties the buffer, its storage is reused.
+func (b *buffer) Reset() {
+	*b = (*b)[:0]
+}
+
+// param is the position of a parameter, formatted by FormatParam, in the Builder's buffer.
+type param struct {
+	start, end int
+	index      int // index of the argument
 }
 
 // Write appends s to the Builder's buffer. Returns the receiver Builder.
@@ -36,6 +101,19 @@ func (b *Builder) WriteTrim(s string) *Builder {
 	return b
 }
 
+// WriteIdent appends the quoted identifier s to the Builder's buffer. A qualified identifier,
+// like "schema.table", is quoted per part. Returns the receiver Builder.
+func (b *Builder) WriteIdent(s string) *Builder {
+	d := b.dialect()
+	for i, part := range strings.Split(s, ".") {
+		if 0 < i {
+			b.s.WriteByte('.')
+		}
+		b.s.WriteString(d.QuoteIdent(part))
+	}
+	return b
+}
+
 // WriteWithArgs appends s with the arguments to the Builder's buffer. Returns the receiver Builder.
 func (b *Builder) WriteWithArgs(s string, a ...interface{}) *Builder {
 	b.s.WriteString(s)
@@ -45,24 +123,125 @@ func (b *Builder) WriteWithArgs(s string, a ...interface{}) *Builder {
 
 // WriteWithParams substitutes every parameter denoted by "{}" in s to a parameter formatted
 // by Builder.FormatParam, and appends it along with the positioned argument from a, to
-// the Builder's buffer. Returns the receiver Builder.
+// the Builder's buffer. A parameter denoted by "{...}" expects a slice (of any element type)
+// as argument, and is substituted by a comma separated parameter for every element,
+// like "id IN ({...})". An empty slice can't be expanded, the error ErrEmptySlice is reported
+// by Err (use the In filter, which matches nothing for an empty slice).
+// If the number of parameters and arguments differ, nothing is appended and the error is
+// reported by Err. Returns the receiver Builder.
 func (b *Builder) WriteWithParams(s string, p ...interface{}) *Builder {
 	if b.FormatParam == nil {
-		// Set default to FixedParam.
-		b.FormatParam = FixedParam
+		// Set default to the parameter format of the dialect.
+		b.FormatParam = b.dialect().FormatParam
+	}
+	// Find the parameters first, nothing is appended when the arguments don't match.
+	var buf [8]int
+	params := buf[:0] // the index of every parameter in s
+	for offset := 0; ; {
+		i, expand := indexParam(s[offset:])
+		if i == -1 {
+			break
+		}
+		params = append(params, offset+i)
+		if expand {
+			offset += i + 5
+		} else {
+			offset += i + 2
+		}
+	}
+	if n := len(params); n != len(p) {
+		err := ErrTooFewArgs
+		if n < len(p) {
+			err = ErrTooManyArgs
+		}
+		b.setErr(fmt.Errorf("%w: %q has %d parameter(s), got %d argument(s)", err, s, n, len(p)))
+		return b
 	}
 	b.s.Grow(len(s))
+	last := 0
+	for j, i := range params {
+		b.s.WriteString(s[last:i])
+		if s[i+1] == '.' { // "{...}"
+			b.writeExpandedParams(p[j])
+			last = i + 5
+		} else {
+			b.writeParam(len(b.args))
+			b.args = append(b.args, p[j])
+			last = i + 2
+		}
+	}
+	b.s.WriteString(s[last:])
+	return b
+}
+
+// indexParam returns the index of the first parameter in s, or -1 if there is none.
+// The parameter is expanded when it's denoted by "{...}".
+func indexParam(s string) (int, bool) {
+	offset := 0
 	for {
-		i := strings.Index(s, "{}")
+		i := strings.IndexByte(s[offset:], '{')
 		if i == -1 {
-			break
+			return -1, false
+		}
+		i += offset
+		switch {
+		case strings.HasPrefix(s[i:], "{}"):
+			return i, false
+		case strings.HasPrefix(s[i:], "{...}"):
+			return i, true
 		}
-		b.s.WriteString(s[:i])
-		b.FormatParam(b, len(b.args))
-		b.args = append(b.args, p[0])
-		p, s = p[1:], s[i+2:] // advance
+		offset = i + 1
 	}
-	b.s.WriteString(s)
+}
+
+// writeExpandedParams writes a parameter for every element of slice, separated by a comma.
+func (b *Builder) writeExpandedParams(slice interface{}) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		b.setErr(fmt.Errorf("argument for {...} must be a slice, not %T", slice))
+		return
+	}
+	if v.Len() == 0 {
+		b.setErr(ErrEmptySlice)
+		return
+	}
+	for i := 0; i < v.Len(); i++ {
+		if 0 < i {
+			b.s.WriteString(", ")
+		}
+		b.writeParam(len(b.args))
+		b.args = append(b.args, v.Index(i).Interface())
+	}
+}
+
+// writeParam writes the parameter with index i, formatted by FormatParam, and records its position.
+func (b *Builder) writeParam(i int) {
+	start := b.s.Len()
+	b.FormatParam(b, i)
+	b.params = append(b.params, param{start, b.s.Len(), i})
+}
+
+// WriteBuilder appends the query of sub along with its arguments to the Builder's buffer, for
+// example as subquery or common table expression. The parameters of sub are formatted again by
+// Builder.FormatParam and renumbered to follow the arguments of the receiver Builder. Only the
+// parameters written by the With(Named)Params methods of sub are renumbered. Returns the
+// receiver Builder.
+func (b *Builder) WriteBuilder(sub *Builder) *Builder {
+	if b.FormatParam == nil {
+		b.FormatParam = b.dialect().FormatParam
+	}
+	if sub.err != nil {
+		b.setErr(sub.err)
+	}
+	s, offset, last := sub.s, len(b.args), 0
+	b.s.Grow(len(s))
+	for _, p := range sub.params {
+		b.s.Write(s[last:p.start])
+		b.writeParam(offset + p.index)
+		last = p.end
+	}
+	b.s.Write(s[last:])
+	b.args = append(b.args, sub.args...)
 	return b
 }
 
@@ -79,6 +258,9 @@ type Values []interface{}
 func (b *Builder) writeWithExpandedValue(s string, p interface{}) {
 	if args, ok := p.(Values); ok {
 		b.WriteWithParams(s, args...)
+	} else if p == nil && !strings.Contains(s, "{}") {
+		// No value, like a boolean column used as condition.
+		b.WriteWithParams(s)
 	} else {
 		b.WriteWithParams(s, p)
 	}
@@ -100,18 +282,28 @@ func (b *Builder) WriteKeyValues(format string, sep string, a ...KeyValue) *Buil
 	return b
 }
 
+// WriteLimitOffset appends a clause to the Builder's buffer which limits the result set to limit rows,
+// starting after offset rows. Returns the receiver Builder.
+func (b *Builder) WriteLimitOffset(limit, offset int) *Builder {
+	return b.WriteWithParams(b.dialect().LimitOffset, limit, offset)
+}
+
 // WithArgs appends a to the Builder's buffer. Returns the receiver Builder.
 func (b *Builder) WithArgs(a ...interface{}) *Builder {
 	b.args = append(b.args, a...)
 	return b
 }
 
-// Copy returns a copy of the receiver Builder.
+// Copy returns a copy of the receiver Builder, including its configuration.
 func (b *Builder) Copy() *Builder {
 	c := &Builder{
-		args: append([]interface{}(nil), b.args...),
+		Dialect:     b.Dialect,
+		FormatParam: b.FormatParam,
+		args:        append([]interface{}(nil), b.args...),
+		params:      append([]param(nil), b.params...),
+		err:         b.err,
 	}
-	c.s.WriteString(b.s.String())
+	c.s = append(c.s, b.s...)
 	return c
 }
 
@@ -120,11 +312,77 @@ func (b *Builder) String() string {
 	return b.s.String()
 }
 
-// Build returns the query string with it's arguments.
+// Build returns the query string with it's arguments. Check Err for any error that
+// occurred while building the query.
 func (b *Builder) Build() (string, []interface{}) {
 	return b.s.String(), b.args
 }
 
+// Err returns the first error that occurred while building the query, if any.
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// setErr sets the error of the Builder, only the first error is kept.
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Reset resets the Builder to be empty, the configuration (Dialect and FormatParam) is kept.
+// The arguments returned by Build must not be used after Reset, their storage is reused, like
+// the storage of the query buffer.
+func (b *Builder) Reset() {
+	b.s.Reset()
+	for i := range b.args {
+		b.args[i] = nil // release the references
+	}
+	b.args = b.args[:0]
+	b.params = b.params[:0]
+	b.err = nil
+}
+
+const (
+	// maxPooledArgs is the maximum capacity of the arguments of a Builder that is put back in the pool.
+	maxPooledArgs = 256
+	// maxPooledQuery is the maximum capacity of the query buffer of a Builder that is put back in the pool.
+	maxPooledQuery = 64 << 10
+)
+
+var builderPool = sync.Pool{
+	New: func() interface{} {
+		return new(Builder)
+	},
+}
+
+// AcquireBuilder returns an empty Builder for dialect d from a pool, this reduces allocations when
+// building many queries. Call ReleaseBuilder when the query and its arguments are no longer used.
+func AcquireBuilder(d *Dialect) *Builder {
+	b := builderPool.Get().(*Builder)
+	b.Dialect = d
+	return b
+}
+
+// ReleaseBuilder resets b and puts it back in the pool. The Builder, and the arguments returned by
+// its Build method, must not be used after ReleaseBuilder.
+func ReleaseBuilder(b *Builder) {
+	if maxPooledArgs < cap(b.args) || maxPooledQuery < cap(b.s) {
+		return // let large Builders be garbage collected
+	}
+	b.Reset()
+	b.Dialect = nil
+	b.FormatParam = nil
+	builderPool.Put(b)
+}
+
+func (b *Builder) dialect() *Dialect {
+	if b.Dialect == nil {
+		return Postgres
+	}
+	return b.Dialect
+}
+
 // FixedParam writes a fixed parameter ($i) to the Builder.
 func FixedParam(b *Builder, i int) {
 	b.s.WriteByte('$')
@@ -135,3 +393,9 @@ func FixedParam(b *Builder, i int) {
 func QuestionMarkParam(b *Builder, i int) {
 	b.s.WriteByte('?')
 }
+
+// NumberedParam writes a numbered question mark parameter (?i) to the Builder.
+func NumberedParam(b *Builder, i int) {
+	b.s.WriteByte('?')
+	b.s.WriteString(strconv.Itoa(i + 1))
+}
@@ -245,3 +245,201 @@ func TestCopy(t *testing.T) {
 	assert.Equal(t, "SELECT $1 AS marked, * FROM users WHERE username = $2", query2)
 	assert.Equal(t, []interface{}{true, "admin"}, args2)
 }
+
+func TestTooFewArgs(t *testing.T) {
+	var b endo.Builder
+	filters := []endo.KeyValue{
+		{"a = {} OR b = {}", 1},
+	}
+
+	b.Write("SELECT * FROM users WHERE ").WriteKeyValues("(%s)", " AND ", filters...)
+
+	assert.ErrorIs(t, b.Err(), endo.ErrTooFewArgs)
+}
+
+func TestTooManyArgs(t *testing.T) {
+	var b endo.Builder
+
+	b.WriteWithParams("SELECT * FROM users WHERE id = {}", 1, 2)
+
+	assert.ErrorIs(t, b.Err(), endo.ErrTooManyArgs)
+}
+
+func TestErrFirstOnly(t *testing.T) {
+	var b endo.Builder
+
+	b.WriteWithParams("WHERE id = {}").WriteWithParams(" AND a = {}", 1, 2)
+
+	assert.ErrorIs(t, b.Err(), endo.ErrTooFewArgs)
+	assert.ErrorIs(t, b.Copy().Err(), endo.ErrTooFewArgs)
+}
+
+func TestNoErr(t *testing.T) {
+	var b endo.Builder
+
+	b.WriteWithParams("SELECT * FROM users WHERE id = {}", 1)
+
+	assert.NoError(t, b.Err())
+}
+
+func TestExpandedParams(t *testing.T) {
+	var b endo.Builder
+
+	query, args := b.
+		Write("SELECT * FROM users ").
+		WriteWithParams("WHERE id IN ({...}) AND active = {}", []int64{1, 2, 3}, true).
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "SELECT * FROM users WHERE id IN ($1, $2, $3) AND active = $4", query)
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3), true}, args)
+}
+
+func TestExpandedParamsEmpty(t *testing.T) {
+	b := endo.Builder{Dialect: endo.MySQL}
+
+	_, args := b.
+		WriteWithParams("SELECT * FROM users WHERE id NOT IN ({...})", []string{}).
+		Build()
+
+	assert.ErrorIs(t, b.Err(), endo.ErrEmptySlice)
+	assert.Empty(t, args)
+}
+
+func TestExpandedParamsNotSlice(t *testing.T) {
+	var b endo.Builder
+
+	b.WriteWithParams("SELECT * FROM users WHERE id IN ({...})", 1)
+
+	assert.Error(t, b.Err())
+}
+
+func TestWriteBuilder(t *testing.T) {
+	var sub endo.Builder
+	sub.Write("SELECT user_id FROM user_roles WHERE ").WriteFilters(endo.In("role_id", []int{1, 2}))
+
+	var b endo.Builder
+	query, args := b.
+		Write("SELECT * FROM users WHERE active = ").WriteWithParams("{}", true).
+		Write(" AND id IN (").WriteBuilder(&sub).Write(")").
+		WriteWithParams(" AND email LIKE {}", "%@example.com").
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "SELECT * FROM users WHERE active = $1 AND id IN (SELECT user_id FROM user_roles WHERE (role_id IN ($2, $3))) AND email LIKE $4", query)
+	assert.Equal(t, []interface{}{true, 1, 2, "%@example.com"}, args)
+}
+
+func TestWriteBuilderCTE(t *testing.T) {
+	var cte endo.Builder
+	cte.WriteWithNamedParams("SELECT id FROM users WHERE created_at > {since} OR updated_at > {since}", map[string]interface{}{
+		"since": "2022-01-01",
+	})
+
+	var b endo.Builder
+	query, args := b.
+		Write("WITH recent AS (").WriteBuilder(&cte).Write(") ").
+		Write("SELECT id FROM recent UNION ").WriteBuilder(cte.Copy().WriteWithParams(" LIMIT {}", 5)).
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "WITH recent AS (SELECT id FROM users WHERE created_at > $1 OR updated_at > $1) SELECT id FROM recent UNION SELECT id FROM users WHERE created_at > $2 OR updated_at > $2 LIMIT $3", query)
+	assert.Equal(t, []interface{}{"2022-01-01", "2022-01-01", 5}, args)
+}
+
+func TestWriteBuilderDialect(t *testing.T) {
+	var sub endo.Builder
+	sub.WriteWithParams("SELECT id FROM roles WHERE name = {}", "admin")
+
+	b := endo.Builder{Dialect: endo.MySQL}
+	query, args := b.
+		WriteWithParams("SELECT * FROM users WHERE active = {} AND role_id = (", true).
+		WriteBuilder(&sub).Write(")").
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "SELECT * FROM users WHERE active = ? AND role_id = (SELECT id FROM roles WHERE name = ?)", query)
+	assert.Equal(t, []interface{}{true, "admin"}, args)
+}
+
+func TestWriteBuilderErr(t *testing.T) {
+	var sub endo.Builder
+	sub.WriteWithParams("SELECT id FROM roles WHERE name = {}")
+
+	var b endo.Builder
+	b.Write("SELECT * FROM users WHERE role_id IN (").WriteBuilder(&sub).Write(")")
+
+	assert.ErrorIs(t, b.Err(), endo.ErrTooFewArgs)
+}
+
+func TestReset(t *testing.T) {
+	b := endo.Builder{Dialect: endo.MySQL}
+	b.WriteWithParams("SELECT * FROM users WHERE id = {}", 1).WriteWithParams("{}")
+
+	b.Reset()
+	query, args := b.WriteWithParams("SELECT * FROM roles WHERE id = {}", 2).Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "SELECT * FROM roles WHERE id = ?", query)
+	assert.Equal(t, []interface{}{2}, args)
+}
+
+func TestCopyFormatParam(t *testing.T) {
+	b := endo.Builder{Dialect: endo.Postgres, FormatParam: endo.QuestionMarkParam}
+	b.Write("SELECT * FROM users")
+
+	query, args := b.Copy().WriteWithParams(" WHERE id = {}", 1).Build()
+
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?", query)
+	assert.Equal(t, []interface{}{1}, args)
+}
+
+func TestAcquireBuilder(t *testing.T) {
+	b := endo.AcquireBuilder(endo.SQLite)
+	query, args := b.WriteWithParams("SELECT * FROM users WHERE id = {}", 1).Build()
+
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?1", query)
+	assert.Equal(t, []interface{}{1}, args)
+	endo.ReleaseBuilder(b)
+
+	b = endo.AcquireBuilder(nil)
+	query, args = b.WriteWithParams("SELECT * FROM users WHERE id = {}", 2).Build()
+
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1", query)
+	assert.Equal(t, []interface{}{2}, args)
+	endo.ReleaseBuilder(b)
+}
+
+func TestBuilderResetQuery(t *testing.T) {
+	var b endo.Builder
+	query, _ := b.Write("SELECT * FROM users").Build()
+	b.Reset()
+	b.Write("DELETE FROM users")
+
+	// The query buffer is reused, but a built query isn't changed.
+	assert.Equal(t, "SELECT * FROM users", query)
+	assert.Equal(t, "DELETE FROM users", b.String())
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var qb endo.Builder
+		qb.Write("SELECT id, email FROM users WHERE ").
+			WriteFilters(endo.Eq("email", "jane@example.com"), endo.Eq("active", true)).
+			WriteLimitOffset(10, 20)
+		qb.Build()
+	}
+}
+
+func BenchmarkAcquireBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		qb := endo.AcquireBuilder(nil)
+		qb.Write("SELECT id, email FROM users WHERE ").
+			WriteFilters(endo.Eq("email", "jane@example.com"), endo.Eq("active", true)).
+			WriteLimitOffset(10, 20)
+		qb.Build()
+		endo.ReleaseBuilder(qb)
+	}
+}
@@ -0,0 +1,170 @@
+package endo
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// OnCommit registers fn to be executed after the transaction of ctx (see InTx) is committed.
+// The callbacks are executed in order of registration. When registered within a savepoint, like
+// a nested InTx, fn is discarded when the savepoint is rolled back. Returns ErrNoTx if ctx doesn't
+// hold a transaction, or the transaction has ended (also within a callback), fn is never executed
+// then. Within a TxFunc, use OnCommitTx.
+func OnCommit(ctx context.Context, fn func()) error {
+	st, ok := ctx.Value(activeTxKey{}).(*txState)
+	if !ok {
+		return ErrNoTx
+	}
+	return st.onCommit(fn)
+}
+
+// OnRollback registers fn to be executed after the transaction of ctx (see InTx) is rolled back.
+// The callbacks are executed in order of registration. When registered within a savepoint, fn
+// is executed when the savepoint is rolled back. Returns ErrNoTx if ctx doesn't hold a transaction.
+// Within a TxFunc, use OnRollbackTx.
+func OnRollback(ctx context.Context, fn func()) error {
+	st, ok := ctx.Value(activeTxKey{}).(*txState)
+	if !ok {
+		return ErrNoTx
+	}
+	return st.onRollback(fn)
+}
+
+// OnCommitTx registers fn to be executed after the transaction of dbtx is committed, like OnCommit.
+// The dbtx is the DBTX passed to the fn of a TxFunc, which must be a transaction begun by UseDB
+// (like a TxMulti call) or InTx. Within a TxMulti call joining a transaction, fn is discarded when
+// its savepoint is rolled back. Returns ErrNoTx if dbtx isn't such a transaction, or the transaction
+// has ended, fn is never executed then.
+func OnCommitTx(dbtx DBTX, fn func()) error {
+	st := txStateOf(dbtx)
+	if st == nil {
+		return ErrNoTx
+	}
+	return st.onCommit(fn)
+}
+
+// OnRollbackTx registers fn to be executed after the transaction of dbtx is rolled back, like
+// OnRollback. See OnCommitTx for the accepted DBTX. Returns ErrNoTx if dbtx isn't such a transaction.
+func OnRollbackTx(dbtx DBTX, fn func()) error {
+	st := txStateOf(dbtx)
+	if st == nil {
+		return ErrNoTx
+	}
+	return st.onRollback(fn)
+}
+
+// txStates are the states of the transactions begun by InTx and UseDB.
+var txStates sync.Map // of *sql.Tx to *txState
+
+// trackTx returns the state of tx, which is tracked until untrackTx is called.
+func trackTx(tx *sql.Tx) *txState {
+	st := &txState{tx: tx}
+	st.push()
+	txStates.Store(tx, st)
+	return st
+}
+
+// untrackTx stops tracking the state of tx.
+func untrackTx(tx *sql.Tx) {
+	txStates.Delete(tx)
+}
+
+// txStateOf returns the state of the transaction which is, or is wrapped by, dbtx. Returns nil
+// if the transaction isn't tracked.
+func txStateOf(dbtx DBTX) *txState {
+	tx := unwrapTx(dbtx)
+	if tx == nil {
+		return nil
+	}
+	if st, ok := txStates.Load(tx); ok {
+		return st.(*txState)
+	}
+	return nil
+}
+
+// txState is the state of a transaction held by a context.
+type txState struct {
+	tx     *sql.Tx
+	scopes []*txCallbacks // the transaction, followed by the nested savepoints
+}
+
+// txCallbacks are the callbacks registered within a transaction or savepoint.
+type txCallbacks struct {
+	commit, rollback []func()
+}
+
+// push starts the scope of a transaction or savepoint.
+func (st *txState) push() {
+	if st != nil {
+		st.scopes = append(st.scopes, new(txCallbacks))
+	}
+}
+
+// onCommit registers fn in the innermost scope. Returns ErrNoTx if the transaction has ended,
+// including while its callbacks are executed.
+func (st *txState) onCommit(fn func()) error {
+	if st.ended() {
+		return ErrNoTx
+	}
+	top := st.top()
+	top.commit = append(top.commit, fn)
+	return nil
+}
+
+// onRollback registers fn in the innermost scope, like onCommit.
+func (st *txState) onRollback(fn func()) error {
+	if st.ended() {
+		return ErrNoTx
+	}
+	top := st.top()
+	top.rollback = append(top.rollback, fn)
+	return nil
+}
+
+// ended returns whether the scope of the transaction itself is popped.
+func (st *txState) ended() bool {
+	return len(st.scopes) == 0
+}
+
+// top returns the scope of the innermost transaction or savepoint.
+func (st *txState) top() *txCallbacks {
+	return st.scopes[len(st.scopes)-1]
+}
+
+// pop ends the innermost scope and returns its callbacks.
+func (st *txState) pop() *txCallbacks {
+	if st == nil {
+		return nil
+	}
+	top := st.top()
+	st.scopes = st.scopes[:len(st.scopes)-1]
+	return top
+}
+
+// release ends the innermost scope of a savepoint, and moves its callbacks to the enclosing scope.
+func (st *txState) release() {
+	if top := st.pop(); top != nil {
+		parent := st.top()
+		parent.commit = append(parent.commit, top.commit...)
+		parent.rollback = append(parent.rollback, top.rollback...)
+	}
+}
+
+// committed executes the OnCommit callbacks.
+func (c *txCallbacks) committed() {
+	if c != nil {
+		for _, fn := range c.commit {
+			fn()
+		}
+	}
+}
+
+// rolledBack executes the OnRollback callbacks.
+func (c *txCallbacks) rolledBack() {
+	if c != nil {
+		for _, fn := range c.rollback {
+			fn()
+		}
+	}
+}
@@ -0,0 +1,175 @@
+//go:build cgo
+// +build cgo
+
+package endo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnCommit(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	tx := endo.UseDB(db)
+	var events []string
+	record := func(event string) func() {
+		return func() { events = append(events, event) }
+	}
+
+	err := endo.InTx(ctx, db, func(ctx context.Context) error {
+		endo.OnCommit(ctx, record("commit 1"))
+		endo.OnRollback(ctx, record("rollback 1"))
+
+		// Nested savepoint which is released.
+		err := endo.InTx(ctx, db, func(ctx context.Context) error {
+			endo.OnCommit(ctx, record("commit 2"))
+			return nil
+		})
+		require.NoError(t, err)
+
+		// Nested savepoint which is rolled back.
+		err = tx(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			endo.OnCommit(ctx, record("commit 3"))
+			endo.OnRollback(ctx, record("rollback 3"))
+			return errors.New("failed")
+		})
+		require.Error(t, err)
+
+		endo.OnCommit(ctx, record("commit 4"))
+		assert.Equal(t, []string{"rollback 3"}, events)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rollback 3", "commit 1", "commit 2", "commit 4"}, events)
+}
+
+func TestOnRollback(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var events []string
+	record := func(event string) func() {
+		return func() { events = append(events, event) }
+	}
+
+	err := endo.InTx(ctx, db, func(ctx context.Context) error {
+		endo.OnCommit(ctx, record("commit 1"))
+		endo.OnRollback(ctx, record("rollback 1"))
+		err := endo.InTx(ctx, db, func(ctx context.Context) error {
+			endo.OnRollback(ctx, record("rollback 2"))
+			return nil
+		})
+		require.NoError(t, err)
+		return errors.New("failed")
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, []string{"rollback 1", "rollback 2"}, events)
+}
+
+func TestOnCommitTx(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	tx := endo.UseDB(db)
+	var events []string
+	record := func(event string) func() {
+		return func() { events = append(events, event) }
+	}
+
+	err := tx(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		require.NoError(t, endo.OnCommitTx(dbtx, func() {
+			// The callback is executed after the commit.
+			n, err := countNames(ctx, tx, endo.TxReadOnly)
+			require.NoError(t, err)
+			events = append(events, fmt.Sprint("commit 1: ", n))
+		}))
+		require.NoError(t, endo.OnRollbackTx(dbtx, record("rollback 1")))
+
+		// Nested savepoint which is rolled back.
+		err := endo.WrapTX(dbtx)(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			require.NoError(t, endo.OnCommitTx(dbtx, record("commit 2")))
+			require.NoError(t, endo.OnRollbackTx(dbtx, record("rollback 2")))
+			return errors.New("failed")
+		})
+		require.Error(t, err)
+		assert.Equal(t, []string{"rollback 2"}, events)
+		return insertName(ctx, dbtx, "a")
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rollback 2", "commit 1: 1"}, events)
+}
+
+func TestOnRollbackTx(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	tx := endo.WithHooks(endo.UseDB(db, endo.Stmts(endo.CachedStmts(db))))
+	var events []string
+	record := func(event string) func() {
+		return func() { events = append(events, event) }
+	}
+
+	err := tx(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		require.NoError(t, endo.OnCommitTx(dbtx, record("commit")))
+		require.NoError(t, endo.OnRollbackTx(dbtx, record("rollback")))
+		assert.Empty(t, events)
+		return errors.New("failed")
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, []string{"rollback"}, events)
+}
+
+func TestOnCommitWithoutTx(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	called := false
+	fn := func() { called = true }
+
+	assert.ErrorIs(t, endo.OnCommit(ctx, fn), endo.ErrNoTx)
+	assert.ErrorIs(t, endo.OnRollback(ctx, fn), endo.ErrNoTx)
+	err := endo.UseDB(db)(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		assert.ErrorIs(t, endo.OnCommitTx(dbtx, fn), endo.ErrNoTx)
+		return endo.OnRollbackTx(dbtx, fn)
+	})
+	assert.ErrorIs(t, err, endo.ErrNoTx)
+	assert.False(t, called)
+}
+
+func TestOnCommitAfterEnd(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var (
+		saved  context.Context
+		events []string
+	)
+
+	err := endo.InTx(ctx, db, func(ctx context.Context) error {
+		saved = ctx
+		return endo.OnCommit(ctx, func() {
+			// Registering within a callback fails, the transaction has ended.
+			assert.ErrorIs(t, endo.OnCommit(ctx, func() { events = append(events, "nested") }), endo.ErrNoTx)
+			assert.ErrorIs(t, endo.OnRollback(ctx, func() { events = append(events, "nested") }), endo.ErrNoTx)
+			events = append(events, "commit")
+		})
+	})
+	require.NoError(t, err)
+	assert.ErrorIs(t, endo.OnCommit(saved, func() { events = append(events, "after") }), endo.ErrNoTx)
+
+	err = endo.UseDB(db)(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		return endo.OnCommitTx(dbtx, func() {
+			assert.ErrorIs(t, endo.OnCommitTx(dbtx, func() { events = append(events, "nested") }), endo.ErrNoTx)
+			events = append(events, "commit tx")
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"commit", "commit tx"}, events)
+}
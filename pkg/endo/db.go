@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync/atomic"
 )
 
 // DBTX represents a database connection or current transaction.
@@ -25,6 +27,18 @@ const (
 	TxMutation = 1 << iota
 	// TxMulti denotes a transaction with multiple statements.
 	TxMulti
+	// TxNew denotes that the transaction of the context (see InTx) must not be joined.
+	TxNew
+	// TxReadCommitted denotes a transaction with the READ COMMITTED isolation level.
+	TxReadCommitted
+	// TxRepeatableRead denotes a transaction with the REPEATABLE READ isolation level.
+	TxRepeatableRead
+	// TxSerializable denotes a transaction with the SERIALIZABLE isolation level.
+	TxSerializable
+	// TxDeferrable denotes a deferrable transaction, which only has effect on a serializable
+	// read-only transaction. Such a transaction waits until it can run without serialization
+	// failures. Only supported by PostgreSQL.
+	TxDeferrable
 
 	// TxReadOnly (default) denotes only read(s), so a read-only transaction will be sufficient.
 	TxReadOnly = 0
@@ -35,45 +49,230 @@ var (
 	ErrNotFound = sql.ErrNoRows
 	// ErrEmptyUpdate is returned when a patch wouldn't modify any record.
 	ErrEmptyUpdate = errors.New("this update would not modify anything")
+	// ErrNoTx is returned when a callback is registered without a transaction, see OnCommit.
+	ErrNoTx = errors.New("no transaction to register the callback")
 )
 
 // A TxFunc opens a new abstact database context and executes fn with it.
 type TxFunc func(ctx context.Context, flags uint, fn func(DBTX) error) error
 
+// An Option configures the TxFunc returned by UseDB.
+type Option func(*dbOptions)
+
+type dbOptions struct {
+	retry *RetryPolicy
+	stmts *StmtCache
+}
+
+// Retry retries the transactions according to policy, see WithRetry. The attempt is reported to
+// the hooks of WithHooks wrapping the returned TxFunc, see QueryInfo.Attempt.
+func Retry(policy RetryPolicy) Option {
+	return func(o *dbOptions) {
+		o.retry = &policy
+	}
+}
+
+// Stmts executes the queries using the prepared statements of c, which must be a StmtCache
+// of the same database. Within a transaction, the statements are bound to the transaction.
+// Preparing a statement, which isn't cached yet, within a transaction requires another
+// connection of the database.
+func Stmts(c *StmtCache) Option {
+	return func(o *dbOptions) {
+		o.stmts = c
+	}
+}
+
 // UseDB wraps db inside a transaction function handler. The returned TxFunc covers the
-// basic functionalities.
-func UseDB(db *sql.DB) TxFunc {
+// basic functionalities. A transaction is started for TxMulti or an isolation flag, like
+// TxSerializable. If ctx holds a transaction of db (see InTx), it's joined unless the TxNew
+// flag is given, a joined transaction keeps its isolation level. Callbacks can be registered
+// on a transaction with OnCommitTx and OnRollbackTx.
+func UseDB(db *sql.DB, opts ...Option) TxFunc {
+	var o dbOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tx := useDB(db, o.stmts)
+	if o.retry != nil {
+		tx = WithRetry(tx, *o.retry)
+	}
+	return tx
+}
+
+func useDB(db *sql.DB, stmts *StmtCache) TxFunc {
+	var plain DBTX = db
+	if stmts != nil {
+		plain = stmts
+	}
+	bind := func(tx *sql.Tx) DBTX {
+		if stmts != nil {
+			return stmts.WithTx(tx)
+		}
+		return tx
+	}
 	return func(ctx context.Context, flags uint, fn func(DBTX) error) error {
-		var (
-			err      error
-			dbTx     DBTX = db
-			activeTx *sql.Tx
-		)
-		if flags&TxMulti != 0 {
-			activeTx, err = db.BeginTx(ctx, &sql.TxOptions{
-				ReadOnly: flags&TxMutation == 0,
-			})
-			if err != nil {
-				return fmt.Errorf("begin transaction: %w", err)
+		if tx := txFromContext(ctx, db); tx != nil && flags&TxNew == 0 {
+			observeJoin(ctx)
+			return WrapTX(bind(tx))(ctx, flags, fn)
+		}
+		if flags&(TxMulti|txIsolation) == 0 {
+			return fn(plain)
+		}
+		tx, err := db.BeginTx(ctx, TxOptions(flags))
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+		if flags&TxDeferrable != 0 {
+			if _, err = tx.ExecContext(ctx, "SET TRANSACTION DEFERRABLE"); err != nil {
+				return fmt.Errorf("set transaction deferrable: %w", err)
 			}
-			defer activeTx.Rollback()
-			dbTx = activeTx
 		}
-		if err = fn(dbTx); err != nil {
+		st := trackTx(tx)
+		defer untrackTx(tx)
+		if err = fn(bind(tx)); err != nil {
+			tx.Rollback()
+			st.pop().rolledBack()
 			return err
 		}
-		if activeTx != nil {
-			if err = activeTx.Commit(); err != nil {
-				return fmt.Errorf("commit transaction: %w", err)
-			}
+		if err = tx.Commit(); err != nil {
+			st.pop().rolledBack()
+			return fmt.Errorf("commit transaction: %w", err)
 		}
+		st.pop().committed()
 		return nil
 	}
 }
 
+// txIsolation are the flags denoting the isolation of a transaction.
+const txIsolation = TxReadCommitted | TxRepeatableRead | TxSerializable | TxDeferrable
+
+// TxOptions returns the transaction options denoted by flags. The strictest isolation level
+// of flags is used.
+func TxOptions(flags uint) *sql.TxOptions {
+	opts := &sql.TxOptions{
+		ReadOnly: flags&TxMutation == 0,
+	}
+	switch {
+	case flags&TxSerializable != 0:
+		opts.Isolation = sql.LevelSerializable
+	case flags&TxRepeatableRead != 0:
+		opts.Isolation = sql.LevelRepeatableRead
+	case flags&TxReadCommitted != 0:
+		opts.Isolation = sql.LevelReadCommitted
+	}
+	return opts
+}
+
+// txContextKey is the context key of the transaction state of db.
+type txContextKey struct {
+	db *sql.DB
+}
+
+// activeTxKey is the context key of the state of the most recent transaction.
+type activeTxKey struct{}
+
+// hasTx returns whether ctx holds a transaction of any database.
+func hasTx(ctx context.Context) bool {
+	return ctx.Value(activeTxKey{}) != nil
+}
+
+// txFromContext returns the transaction of db in ctx, or nil if there is none.
+func txFromContext(ctx context.Context, db *sql.DB) *sql.Tx {
+	if st, ok := ctx.Value(txContextKey{db}).(*txState); ok {
+		return st.tx
+	}
+	return nil
+}
+
+// InTx begins a read-write transaction on db and executes fn with a context holding the transaction.
+// Every TxFunc of db (see UseDB) called with this context joins the transaction, so store methods
+// of multiple stores and packages can be composed. The transaction is committed when fn succeeds,
+// otherwise it's rolled back. If ctx already holds a transaction of db, fn is nested inside a savepoint.
+// Callbacks can be registered with OnCommit and OnRollback, or OnCommitTx and OnRollbackTx within a TxFunc
+// joining the transaction. The transaction must not be used concurrently.
+func InTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if tx := txFromContext(ctx, db); tx != nil {
+		return savepoint(ctx, tx, func(DBTX) error {
+			return fn(ctx)
+		})
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	st := trackTx(tx)
+	defer untrackTx(tx)
+	ctx = context.WithValue(ctx, txContextKey{db}, st)
+	if err = fn(context.WithValue(ctx, activeTxKey{}, st)); err != nil {
+		tx.Rollback()
+		st.pop().rolledBack()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		st.pop().rolledBack()
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	st.pop().committed()
+	return nil
+}
+
 // WrapTx wraps a DBTX in a TxFunc. This can be helpful to reuse a DBTX for a separate store.
+// If tx is a transaction, every TxMulti call is nested inside a savepoint, which is rolled back
+// when fn returns an error, without aborting the enclosing transaction.
 func WrapTX(tx DBTX) TxFunc {
 	return func(ctx context.Context, flags uint, fn func(DBTX) error) error {
+		if isTx(tx) && flags&TxMulti != 0 {
+			return savepoint(ctx, tx, fn)
+		}
 		return fn(tx)
 	}
 }
+
+// isTx returns whether dbtx is a *sql.Tx, or wraps one.
+func isTx(dbtx DBTX) bool {
+	return unwrapTx(dbtx) != nil
+}
+
+// unwrapTx returns the *sql.Tx which is, or is wrapped by, dbtx. Returns nil if there is none.
+func unwrapTx(dbtx DBTX) *sql.Tx {
+	for {
+		switch v := dbtx.(type) {
+		case *sql.Tx:
+			return v
+		case interface{ Unwrap() DBTX }:
+			dbtx = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// savepointID is used to generate unique savepoint names.
+var savepointID uint64
+
+// savepoint executes fn inside a new savepoint of tx. The savepoint is released when fn succeeds,
+// otherwise the transaction is rolled back to the savepoint. The OnRollback callbacks registered
+// within the savepoint are executed after the rollback, and the OnCommit callbacks are discarded.
+func savepoint(ctx context.Context, tx DBTX, fn func(DBTX) error) error {
+	name := "endo_" + strconv.FormatUint(atomic.AddUint64(&savepointID, 1), 10)
+	st := txStateOf(tx)
+	ctx = context.WithValue(ctx, noStmtCacheKey{}, true) // the statements are used once
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("create savepoint: %w", err)
+	}
+	st.push()
+	if err := fn(tx); err != nil {
+		_, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		st.pop().rolledBack()
+		if rbErr != nil {
+			return fmt.Errorf("rollback to savepoint: %v (after: %w)", rbErr, err)
+		}
+		return err
+	}
+	st.release()
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
+}
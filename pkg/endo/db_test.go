@@ -0,0 +1,222 @@
+//go:build cgo
+// +build cgo
+
+package endo_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openDB opens a new SQLite database with a names table. The tests using a database are
+// built with cgo only, which the sqlite3 driver requires.
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("CREATE TABLE names (name TEXT NOT NULL)")
+	require.NoError(t, err)
+	return db
+}
+
+func insertName(ctx context.Context, dbtx endo.DBTX, name string) error {
+	_, err := dbtx.ExecContext(ctx, "INSERT INTO names (name) VALUES (?1)", name)
+	return err
+}
+
+func selectNames(t *testing.T, db *sql.DB) []string {
+	rows, err := db.Query("SELECT name FROM names ORDER BY name")
+	require.NoError(t, err)
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	return names
+}
+
+func TestWrapTXSavepoint(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	errNested := errors.New("nested")
+
+	err := endo.UseDB(db)(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		if err := insertName(ctx, dbtx, "a"); err != nil {
+			return err
+		}
+		nested := endo.WrapTX(dbtx)
+		err := nested(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			if err := insertName(ctx, dbtx, "b"); err != nil {
+				return err
+			}
+			return errNested
+		})
+		assert.ErrorIs(t, err, errNested)
+		return nested(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			return insertName(ctx, dbtx, "c")
+		})
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, selectNames(t, db))
+}
+
+func countNames(ctx context.Context, tx endo.TxFunc, flags uint) (n int, err error) {
+	err = tx(ctx, flags, func(dbtx endo.DBTX) error {
+		return dbtx.QueryRowContext(ctx, "SELECT COUNT(*) FROM names").Scan(&n)
+	})
+	return
+}
+
+func TestInTx(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	tx := endo.UseDB(db)
+
+	err := endo.InTx(ctx, db, func(ctx context.Context) error {
+		err := tx(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+			return insertName(ctx, dbtx, "a")
+		})
+		if err != nil {
+			return err
+		}
+
+		// Joins the transaction, which isn't committed yet.
+		n, err := countNames(ctx, tx, endo.TxReadOnly)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		n, err = countNames(ctx, tx, endo.TxMulti)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		// Opts out of the transaction.
+		n, err = countNames(ctx, tx, endo.TxNew)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, selectNames(t, db))
+}
+
+func TestInTxRollback(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	tx := endo.UseDB(db)
+	errFailed := errors.New("failed")
+
+	err := endo.InTx(ctx, db, func(ctx context.Context) error {
+		err := tx(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+			return insertName(ctx, dbtx, "a")
+		})
+		if err != nil {
+			return err
+		}
+		err = endo.InTx(ctx, db, func(ctx context.Context) error {
+			if err := tx(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+				return insertName(ctx, dbtx, "b")
+			}); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+
+		// Only the nested transaction is rolled back.
+		n, err := countNames(ctx, tx, endo.TxReadOnly)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		return errFailed
+	})
+
+	assert.ErrorIs(t, err, errFailed)
+	assert.Empty(t, selectNames(t, db))
+}
+
+func TestUseDBIsolation(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	// A transaction is started for the isolation flag, so the insert is rolled back.
+	err := endo.UseDB(db)(ctx, endo.TxMutation|endo.TxSerializable, func(dbtx endo.DBTX) error {
+		if _, ok := dbtx.(*sql.Tx); !ok {
+			t.Error("dbtx must be a transaction")
+		}
+		if err := insertName(ctx, dbtx, "a"); err != nil {
+			return err
+		}
+		return errors.New("failed")
+	})
+
+	assert.Error(t, err)
+	assert.Empty(t, selectNames(t, db))
+}
+
+func TestUseDBRetry(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	tx := endo.UseDB(db, endo.Retry(fastRetry))
+
+	attempts := 0
+	err := tx(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		attempts++
+		if err := insertName(ctx, dbtx, fmt.Sprint(attempts)); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []string{"2"}, selectNames(t, db))
+}
+
+func TestUseDBRetryInTx(t *testing.T) {
+	ctx := context.Background()
+	dbA, dbB := openDB(t), openDB(t)
+	txA, txB := endo.UseDB(dbA, endo.Retry(fastRetry)), endo.UseDB(dbB, endo.Retry(fastRetry))
+
+	var attemptsA, attemptsB int
+	err := endo.InTx(ctx, dbA, func(ctx context.Context) error {
+		// The transaction of dbA is joined, which can't be retried.
+		err := txA(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			attemptsA++
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		})
+		assert.Error(t, err)
+
+		// The transaction of dbB is begun by txB, which is retried.
+		return txB(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			attemptsB++
+			if err := insertName(ctx, dbtx, fmt.Sprint(attemptsB)); err != nil {
+				return err
+			}
+			if attemptsB == 1 {
+				return sqlite3.Error{Code: sqlite3.ErrBusy}
+			}
+			return nil
+		})
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attemptsA)
+	assert.Equal(t, 2, attemptsB)
+	assert.Equal(t, []string{"2"}, selectNames(t, dbB))
+}
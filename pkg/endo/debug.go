@@ -0,0 +1,126 @@
+package endo
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// debugPrefix marks an interpolated query as not for execution.
+const debugPrefix = "/* DEBUG ONLY, DO NOT EXECUTE */ "
+
+// DebugString returns the query of the Builder with the arguments interpolated, see Interpolate.
+func (b *Builder) DebugString() string {
+	return Interpolate(b.s.String(), b.args, b.dialect())
+}
+
+// Interpolate returns a human readable query, with every parameter substituted by the escaped literal
+// of its argument. Strings, times, byte slices, numbers, booleans and driver.Valuer types (like sql.NullString)
+// are supported. The parameters are recognized by the format of dialect d, if d is nil Postgres is used.
+// The result is marked as not for execution, it must only be used for debugging and logging.
+func Interpolate(query string, args []interface{}, d *Dialect) string {
+	if d == nil {
+		d = Postgres
+	}
+	numbered := (&Builder{FormatParam: d.FormatParam}).numberedParams()
+	var (
+		b    strings.Builder
+		next int // index of the next positional argument
+	)
+	b.Grow(len(debugPrefix) + len(query) + len(args)*8)
+	b.WriteString(debugPrefix)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == d.IdentQuote:
+			// Skip literals and quoted identifiers.
+			j := indexClosingQuote(query, i)
+			b.WriteString(query[i:j])
+			i = j - 1
+			continue
+		case c == '$' || c == '?':
+			j := i + 1
+			for j < len(query) && '0' <= query[j] && query[j] <= '9' {
+				j++
+			}
+			index := -1
+			if i+1 < j {
+				index, _ = strconv.Atoi(query[i+1 : j])
+				index-- // parameters are numbered from 1
+			} else if c == '?' && !numbered {
+				index = next
+				next++
+			}
+			if 0 <= index && index < len(args) {
+				b.WriteString(debugLiteral(d, args[index]))
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// indexClosingQuote returns the index after the closing quote of the quote starting at i.
+// A quote is escaped by doubling it.
+func indexClosingQuote(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == q {
+			if j+1 < len(s) && s[j+1] == q {
+				j++ // escaped quote
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// debugLiteral returns v as escaped SQL literal for dialect d.
+func debugLiteral(d *Dialect, v interface{}) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return fmt.Sprintf("/* %v */ NULL", err)
+		}
+	}
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteString(d, v)
+	case []byte:
+		if d == Postgres {
+			return `'\x` + hex.EncodeToString(v) + `'`
+		}
+		return "X'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999999Z07:00") + "'"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case fmt.Stringer:
+		return quoteString(d, v.String())
+	default:
+		return quoteString(d, fmt.Sprint(v))
+	}
+}
+
+// quoteString returns s as string literal for dialect d.
+func quoteString(d *Dialect, s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	if d == MySQL {
+		// MySQL treats a backslash as escape character.
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'"
+}
@@ -0,0 +1,38 @@
+package endo_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugString(t *testing.T) {
+	var b endo.Builder
+	b.Write(`UPDATE users SET "order" = '$1', `).
+		WriteWithParams("email = {}, first_name = {}, last_name = {}, email_verified = {}, avatar = {}, updated_at = {} WHERE id = {}",
+			"o'reilly@example.com",
+			sql.NullString{String: "Jane", Valid: true},
+			sql.NullString{},
+			true,
+			[]byte{0xde, 0xad},
+			time.Date(2022, 1, 29, 15, 4, 5, 0, time.UTC),
+			int64(10),
+		)
+
+	assert.Equal(t, `/* DEBUG ONLY, DO NOT EXECUTE */ UPDATE users SET "order" = '$1', email = 'o''reilly@example.com', first_name = 'Jane', last_name = NULL, email_verified = TRUE, avatar = '\xdead', updated_at = '2022-01-29 15:04:05Z' WHERE id = 10`, b.DebugString())
+}
+
+func TestInterpolate(t *testing.T) {
+	args := []interface{}{`C:\temp`, 1.5, []byte("a")}
+
+	assert.Equal(t, `/* DEBUG ONLY, DO NOT EXECUTE */ SELECT * FROM files WHERE path = 'C:\\temp' AND size > 1.5 AND data = X'61' AND tag = '?'`,
+		endo.Interpolate("SELECT * FROM files WHERE path = ? AND size > ? AND data = ? AND tag = '?'", args, endo.MySQL))
+	assert.Equal(t, `/* DEBUG ONLY, DO NOT EXECUTE */ SELECT * FROM files WHERE path = 'C:\temp' AND (size > 1.5 OR size < 1.5) AND data = X'61'`,
+		endo.Interpolate("SELECT * FROM files WHERE path = ?1 AND (size > ?2 OR size < ?2) AND data = ?3", args, endo.SQLite))
+	assert.Equal(t, `/* DEBUG ONLY, DO NOT EXECUTE */ SELECT * FROM files WHERE path = 'C:\temp' AND tags ? 'a' AND id = $4`,
+		endo.Interpolate("SELECT * FROM files WHERE path = $1 AND tags ? 'a' AND id = $4", args, nil))
+}
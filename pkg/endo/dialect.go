@@ -0,0 +1,84 @@
+package endo
+
+import "strings"
+
+// A Dialect describes the differences in SQL syntax between databases.
+type Dialect struct {
+	// Name is the name of the dialect.
+	Name string
+	// FormatParam formats a parameter with index i.
+	FormatParam func(b *Builder, i int)
+	// IdentQuote is the character used to quote identifiers.
+	IdentQuote byte
+	// ReservedWords is the set of (upper case) words which must be quoted when used as identifier.
+	ReservedWords map[string]struct{}
+	// Returning denotes whether the RETURNING clause is supported.
+	Returning bool
+	// ForUpdate denotes whether the FOR UPDATE clause is supported, to lock the selected rows.
+	ForUpdate bool
+	// LimitOffset is the clause limiting the result set, the first parameter "{}" denotes
+	// the limit and the second parameter denotes the offset.
+	LimitOffset string
+}
+
+var (
+	// Postgres is the dialect of PostgreSQL.
+	Postgres = &Dialect{
+		Name:          "postgres",
+		FormatParam:   FixedParam,
+		IdentQuote:    '"',
+		ReservedWords: postgresReservedWords,
+		Returning:     true,
+		ForUpdate:     true,
+		LimitOffset:   "LIMIT {} OFFSET {}",
+	}
+	// MySQL is the dialect of MySQL and MariaDB.
+	MySQL = &Dialect{
+		Name:          "mysql",
+		FormatParam:   QuestionMarkParam,
+		IdentQuote:    '`',
+		ReservedWords: mysqlReservedWords,
+		Returning:     false,
+		ForUpdate:     true,
+		LimitOffset:   "LIMIT {} OFFSET {}",
+	}
+	// SQLite is the dialect of SQLite. The RETURNING clause requires SQLite 3.35 or later.
+	SQLite = &Dialect{
+		Name:          "sqlite",
+		FormatParam:   NumberedParam,
+		IdentQuote:    '"',
+		ReservedWords: sqliteKeywords,
+		Returning:     true,
+		ForUpdate:     false,
+		LimitOffset:   "LIMIT {} OFFSET {}",
+	}
+)
+
+// QuoteIdent quotes the identifier s. Any quote characters inside s are escaped.
+func (d *Dialect) QuoteIdent(s string) string {
+	q := string(d.IdentQuote)
+	return q + strings.ReplaceAll(s, q, q+q) + q
+}
+
+// IsReserved returns whether word is a reserved word in the dialect.
+func (d *Dialect) IsReserved(word string) bool {
+	_, ok := d.ReservedWords[strings.ToUpper(word)]
+	return ok
+}
+
+// NeedsQuote returns whether the identifier s must be quoted. This is the case when s is
+// a reserved word, or when s isn't a plain lower case identifier.
+func (d *Dialect) NeedsQuote(s string) bool {
+	if s == "" || d.IsReserved(s) {
+		return true
+	}
+	for i, c := range s {
+		switch {
+		case 'a' <= c && c <= 'z', c == '_':
+		case '0' <= c && c <= '9' && 0 < i:
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package endo_test
+
+import (
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectQuoteIdent(t *testing.T) {
+	assert.Equal(t, `"users"`, endo.Postgres.QuoteIdent("users"))
+	assert.Equal(t, `"my""table"`, endo.Postgres.QuoteIdent(`my"table`))
+	assert.Equal(t, "`users`", endo.MySQL.QuoteIdent("users"))
+	assert.Equal(t, "`my``table`", endo.MySQL.QuoteIdent("my`table"))
+}
+
+func TestMySQLBuilder(t *testing.T) {
+	b := endo.Builder{Dialect: endo.MySQL}
+
+	query, args := b.
+		Write("SELECT id, email FROM users ").
+		WriteWithParams("WHERE active = {} ", true).
+		WriteLimitOffset(100, 200).
+		Build()
+
+	assert.Equal(t, "SELECT id, email FROM users WHERE active = ? LIMIT ? OFFSET ?", query)
+	assert.Equal(t, []interface{}{true, 100, 200}, args)
+}
+
+func TestDefaultDialectLimitOffset(t *testing.T) {
+	var b endo.Builder
+
+	query, args := b.
+		Write("SELECT id, email FROM users ").
+		WriteLimitOffset(10, 0).
+		Build()
+
+	assert.Equal(t, "SELECT id, email FROM users LIMIT $1 OFFSET $2", query)
+	assert.Equal(t, []interface{}{10, 0}, args)
+}
+
+func TestCopyDialect(t *testing.T) {
+	b := endo.Builder{Dialect: endo.MySQL}
+	b.Write("SELECT * FROM users")
+
+	query := b.Copy().WriteWithParams(" WHERE id = {}", 1).String()
+
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?", query)
+}
+
+func TestSQLiteBuilder(t *testing.T) {
+	b := endo.Builder{Dialect: endo.SQLite}
+
+	query, args := b.
+		Write("SELECT id, email FROM users ").
+		WriteWithParams("WHERE email = {} OR display_name = {} ", "admin", "Admin").
+		WriteLimitOffset(1, 0).
+		Build()
+
+	assert.Equal(t, "SELECT id, email FROM users WHERE email = ?1 OR display_name = ?2 LIMIT ?3 OFFSET ?4", query)
+	assert.Equal(t, []interface{}{"admin", "Admin", 1, 0}, args)
+}
+
+func TestDialectNeedsQuote(t *testing.T) {
+	cases := []struct {
+		Dialect *endo.Dialect
+		Ident   string
+		Quote   bool
+	}{
+		{endo.Postgres, "users", false},
+		{endo.Postgres, "first_name", false},
+		{endo.Postgres, "user", true},
+		{endo.Postgres, "Order", true},
+		{endo.Postgres, "firstName", true},
+		{endo.Postgres, "2fa", true},
+		{endo.Postgres, "key", false},
+		{endo.MySQL, "user", false},
+		{endo.MySQL, "key", true},
+		{endo.SQLite, "key", true},
+		{endo.SQLite, "order", true},
+	}
+
+	for _, test := range cases {
+		assert.Equal(t, test.Quote, test.Dialect.NeedsQuote(test.Ident), "%s: %s", test.Dialect.Name, test.Ident)
+	}
+}
+
+func TestWriteIdent(t *testing.T) {
+	var b endo.Builder
+
+	query := b.
+		Write("SELECT ").WriteIdent("order").
+		Write(" FROM ").WriteIdent("public.user").
+		String()
+
+	assert.Equal(t, `SELECT "order" FROM "public"."user"`, query)
+
+	mb := endo.Builder{Dialect: endo.MySQL}
+	query = mb.Write("SELECT * FROM ").WriteIdent("order").String()
+
+	assert.Equal(t, "SELECT * FROM `order`", query)
+}
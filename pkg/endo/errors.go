@@ -0,0 +1,255 @@
+package endo
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUniqueViolation is reported when a unique constraint or primary key is violated.
+	ErrUniqueViolation = errors.New("unique violation")
+	// ErrForeignKeyViolation is reported when a foreign key constraint is violated.
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrCheckViolation is reported when a check constraint is violated.
+	ErrCheckViolation = errors.New("check violation")
+	// ErrNotNullViolation is reported when a NOT NULL constraint is violated.
+	ErrNotNullViolation = errors.New("not null violation")
+	// ErrSerialization is reported when a transaction failed due to a serialization failure or deadlock.
+	ErrSerialization = errors.New("serialization failure")
+)
+
+// A DBError is a classified driver error, see ClassifyError. It matches its Kind with errors.Is,
+// like ErrUniqueViolation, and unwraps to the driver error.
+type DBError struct {
+	// Kind is the class of the error, like ErrUniqueViolation.
+	Kind error
+	// Constraint, Table and Column are the names of the violated constraint and its table and
+	// column, if reported by the driver.
+	Constraint string
+	Table      string
+	Column     string
+	// Err is the classified error.
+	Err error
+}
+
+func (e *DBError) Error() string {
+	return e.Err.Error()
+}
+
+// Is returns whether target is the Kind of the error.
+func (e *DBError) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *DBError) Unwrap() error {
+	return e.Err
+}
+
+// A Classifier classifies a driver error. It returns nil if err isn't recognized. The Err field of the
+// returned DBError is set by ClassifyError.
+type Classifier func(err error) *DBError
+
+var (
+	classifiersMu sync.RWMutex
+	classifiers   = []Classifier{classifyPostgres, classifyMySQL, classifySQLite}
+)
+
+// RegisterClassifier registers c to classify driver errors. Classifiers are tried in reverse order of
+// registration, before the built-in classifiers of PostgreSQL (lib/pq and pgx), MySQL and SQLite errors.
+func RegisterClassifier(c Classifier) {
+	classifiersMu.Lock()
+	classifiers = append([]Classifier{c}, classifiers...)
+	classifiersMu.Unlock()
+}
+
+// ClassifyError wraps the first driver error in the chain of err, which is recognized by a Classifier,
+// in a DBError. Returns err if it isn't recognized or already classified. The generated store methods
+// classify their errors, so a unique violation can be detected with errors.Is(err, ErrUniqueViolation).
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var dbErr *DBError
+	if errors.As(err, &dbErr) {
+		return err
+	}
+	classifiersMu.RLock()
+	defer classifiersMu.RUnlock()
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		for _, c := range classifiers {
+			if dbErr = c(e); dbErr != nil {
+				dbErr.Err = err
+				return dbErr
+			}
+		}
+	}
+	return err
+}
+
+// An OpError is the error of a store operation, it unwraps to the cause, like ErrNotFound or the
+// (classified) driver error. The generated store methods wrap their errors in an OpError.
+type OpError struct {
+	// Model is the name of the model, like "User".
+	Model string
+	// Op is the store method, like "Store.CreateUser".
+	Op string
+	// Query is the query of the operation, if any.
+	Query string
+	// Err is the cause of the error.
+	Err error
+}
+
+func (e *OpError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// WrapOpError wraps err, which is classified by ClassifyError, in an OpError. Returns nil if err is nil,
+// or err if it's already an OpError, so a store method delegating to another keeps the innermost one.
+func WrapOpError(model, op, query string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var opErr *OpError
+	if errors.As(err, &opErr) {
+		return err
+	}
+	return &OpError{Model: model, Op: op, Query: query, Err: ClassifyError(err)}
+}
+
+// postgresKinds are the kinds of the PostgreSQL error codes (SQLSTATE).
+var postgresKinds = map[string]error{
+	"23505": ErrUniqueViolation,     // unique_violation
+	"23503": ErrForeignKeyViolation, // foreign_key_violation
+	"23514": ErrCheckViolation,      // check_violation
+	"23502": ErrNotNullViolation,    // not_null_violation
+	"40001": ErrSerialization,       // serialization_failure
+	"40P01": ErrSerialization,       // deadlock_detected
+}
+
+// classifyPostgres classifies errors of lib/pq and pgx, or any other error with a SQLSTATE.
+func classifyPostgres(err error) *DBError {
+	code := driverErrorCode(err)
+	kind, ok := postgresKinds[code]
+	if !ok {
+		return nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(err))
+	return &DBError{
+		Kind:       kind,
+		Constraint: stringField(v, "Constraint", "ConstraintName"),
+		Table:      stringField(v, "Table", "TableName"),
+		Column:     stringField(v, "Column", "ColumnName"),
+	}
+}
+
+// stringField returns the first string field of struct v with one of names.
+func stringField(v reflect.Value, names ...string) string {
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, name := range names {
+		if f := v.FieldByName(name); f.IsValid() && f.Kind() == reflect.String {
+			return f.String()
+		}
+	}
+	return ""
+}
+
+var (
+	mysqlDuplicateRegexp  = regexp.MustCompile("for key '([^']+)'")
+	mysqlForeignKeyRegexp = regexp.MustCompile("`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`\\)")
+	mysqlCheckRegexp      = regexp.MustCompile("Check constraint '([^']+)'")
+	mysqlColumnRegexp     = regexp.MustCompile("Column '([^']+)'")
+)
+
+// classifyMySQL classifies errors of go-sql-driver/mysql. The names are parsed from the message.
+func classifyMySQL(err error) *DBError {
+	code := driverErrorCode(err)
+	if !strings.HasPrefix(code, "mysql:") {
+		return nil
+	}
+	msg := reflect.Indirect(reflect.ValueOf(err)).FieldByName("Message").String()
+	switch code {
+	case "mysql:1062": // ER_DUP_ENTRY
+		e := &DBError{Kind: ErrUniqueViolation}
+		if m := mysqlDuplicateRegexp.FindStringSubmatch(msg); m != nil {
+			key := m[1]
+			// Since MySQL 8.0.19, the key is qualified by its table.
+			if i := strings.LastIndexByte(key, '.'); i != -1 {
+				e.Table, key = key[:i], key[i+1:]
+			}
+			e.Constraint = key
+		}
+		return e
+	case "mysql:1451", "mysql:1452": // ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+		e := &DBError{Kind: ErrForeignKeyViolation}
+		if m := mysqlForeignKeyRegexp.FindStringSubmatch(msg); m != nil {
+			e.Table, e.Constraint, e.Column = m[1], m[2], m[3]
+		}
+		return e
+	case "mysql:3819": // ER_CHECK_CONSTRAINT_VIOLATED
+		e := &DBError{Kind: ErrCheckViolation}
+		if m := mysqlCheckRegexp.FindStringSubmatch(msg); m != nil {
+			e.Constraint = m[1]
+		}
+		return e
+	case "mysql:1048": // ER_BAD_NULL_ERROR
+		e := &DBError{Kind: ErrNotNullViolation}
+		if m := mysqlColumnRegexp.FindStringSubmatch(msg); m != nil {
+			e.Column = m[1]
+		}
+		return e
+	case "mysql:1213": // ER_LOCK_DEADLOCK
+		return &DBError{Kind: ErrSerialization}
+	}
+	return nil
+}
+
+// sqliteKinds are the kinds of the SQLite extended result codes.
+var sqliteKinds = map[int64]error{
+	2067: ErrUniqueViolation,     // SQLITE_CONSTRAINT_UNIQUE
+	1555: ErrUniqueViolation,     // SQLITE_CONSTRAINT_PRIMARYKEY
+	787:  ErrForeignKeyViolation, // SQLITE_CONSTRAINT_FOREIGNKEY
+	275:  ErrCheckViolation,      // SQLITE_CONSTRAINT_CHECK
+	1299: ErrNotNullViolation,    // SQLITE_CONSTRAINT_NOTNULL
+}
+
+// classifySQLite classifies errors of mattn/go-sqlite3. The names are parsed from the message,
+// like "UNIQUE constraint failed: users.email".
+func classifySQLite(err error) *DBError {
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct || v.Type().PkgPath() != "github.com/mattn/go-sqlite3" || v.Type().Name() != "Error" {
+		return nil
+	}
+	kind, ok := sqliteKinds[v.FieldByName("ExtendedCode").Int()]
+	if !ok {
+		return nil
+	}
+	e := &DBError{Kind: kind}
+	i := strings.Index(err.Error(), "constraint failed: ")
+	if i == -1 {
+		return e
+	}
+	name := err.Error()[i+len("constraint failed: "):]
+	switch {
+	case kind == ErrCheckViolation:
+		e.Constraint = name
+	case strings.HasPrefix(name, "index '"):
+		e.Constraint = strings.Trim(name[len("index "):], "'")
+	case !strings.Contains(name, ", "):
+		// A single column of a table, multiple columns are separated by a comma.
+		if j := strings.IndexByte(name, '.'); j != -1 {
+			e.Table, e.Column = name[:j], name[j+1:]
+		}
+	default:
+		e.Table = name[:strings.IndexByte(name+".", '.')]
+	}
+	return e
+}
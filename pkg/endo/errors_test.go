@@ -0,0 +1,97 @@
+package endo_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pgError has the fields of a pgx PgError.
+type pgError struct {
+	Code           string
+	ConstraintName string
+	TableName      string
+	ColumnName     string
+}
+
+func (e *pgError) Error() string    { return "ERROR (SQLSTATE " + e.Code + ")" }
+func (e *pgError) SQLState() string { return e.Code }
+
+func TestClassifyErrorPostgres(t *testing.T) {
+	err := endo.ClassifyError(fmt.Errorf("create user: %w", &pgError{
+		Code:           "23505",
+		ConstraintName: "users_email_key",
+		TableName:      "users",
+	}))
+	assert.ErrorIs(t, err, endo.ErrUniqueViolation)
+	assert.NotErrorIs(t, err, endo.ErrForeignKeyViolation)
+	assert.EqualError(t, err, "create user: ERROR (SQLSTATE 23505)")
+
+	var dbErr *endo.DBError
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "users_email_key", dbErr.Constraint)
+	assert.Equal(t, "users", dbErr.Table)
+	assert.Empty(t, dbErr.Column)
+
+	var pgErr *pgError
+	assert.ErrorAs(t, err, &pgErr)
+
+	assert.ErrorIs(t, endo.ClassifyError(stateError("23503")), endo.ErrForeignKeyViolation)
+	assert.ErrorIs(t, endo.ClassifyError(stateError("23514")), endo.ErrCheckViolation)
+	assert.ErrorIs(t, endo.ClassifyError(stateError("23502")), endo.ErrNotNullViolation)
+	assert.ErrorIs(t, endo.ClassifyError(stateError("40P01")), endo.ErrSerialization)
+	assert.Equal(t, stateError("42P01"), endo.ClassifyError(stateError("42P01")))
+}
+
+func TestClassifyErrorUnknown(t *testing.T) {
+	assert.NoError(t, endo.ClassifyError(nil))
+	assert.Equal(t, endo.ErrNotFound, endo.ClassifyError(endo.ErrNotFound))
+
+	err := endo.ClassifyError(stateError("23505"))
+	assert.Same(t, err, endo.ClassifyError(err))
+}
+
+func TestRegisterClassifier(t *testing.T) {
+	endo.RegisterClassifier(func(err error) *endo.DBError {
+		if strings.HasPrefix(err.Error(), "duplicate key") {
+			return &endo.DBError{Kind: endo.ErrUniqueViolation, Constraint: strings.TrimPrefix(err.Error(), "duplicate key ")}
+		}
+		return nil
+	})
+
+	err := endo.ClassifyError(errors.New("duplicate key users_pkey"))
+	assert.ErrorIs(t, err, endo.ErrUniqueViolation)
+	var dbErr *endo.DBError
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "users_pkey", dbErr.Constraint)
+}
+
+func TestIsRetryableClassified(t *testing.T) {
+	assert.True(t, endo.IsRetryable(&endo.DBError{Kind: endo.ErrSerialization, Err: errors.New("deadlock")}))
+	assert.False(t, endo.IsRetryable(&endo.DBError{Kind: endo.ErrUniqueViolation, Err: errors.New("duplicate")}))
+}
+
+func TestWrapOpError(t *testing.T) {
+	assert.NoError(t, endo.WrapOpError("User", "Store.GetUser", "SELECT", nil))
+
+	err := endo.WrapOpError("User", "Store.GetUser", "SELECT", endo.ErrNotFound)
+	assert.ErrorIs(t, err, endo.ErrNotFound)
+	assert.EqualError(t, err, "Store.GetUser: sql: no rows in result set")
+	var opErr *endo.OpError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, &endo.OpError{Model: "User", Op: "Store.GetUser", Query: "SELECT", Err: endo.ErrNotFound}, opErr)
+
+	// The innermost OpError is kept.
+	assert.Same(t, err, endo.WrapOpError("User", "Store.UpdateUser", "", err))
+
+	err = endo.WrapOpError("User", "Store.CreateUser", "INSERT", stateError("23505"))
+	assert.ErrorIs(t, err, endo.ErrUniqueViolation)
+	var state stateError
+	assert.ErrorAs(t, err, &state)
+}
@@ -0,0 +1,185 @@
+package endo
+
+import (
+	"reflect"
+	"strings"
+)
+
+// A Filter is a condition, like the WHERE clause of a query, which is written to a Builder.
+// KeyValue is a Filter too, its Key is written with the Value as parameter.
+type Filter interface {
+	// WriteFilter writes the condition to b.
+	WriteFilter(b *Builder)
+}
+
+// WriteFilters writes every filter enclosed in parentheses, separated by AND, to the
+// Builder's buffer. Returns the receiver Builder.
+func (b *Builder) WriteFilters(filters ...Filter) *Builder {
+	writeJunction(b, " AND ", filters)
+	return b
+}
+
+// WriteFilter implements Filter. The Key is written with the Value as parameter(s), see WriteKeyValues.
+func (kv KeyValue) WriteFilter(b *Builder) {
+	b.writeWithExpandedValue(kv.Key, kv.Value)
+}
+
+// Eq returns a Filter which matches when column equals v. If v is nil, the Filter matches
+// when column IS NULL.
+func Eq(column string, v interface{}) Filter {
+	if isNil(v) {
+		return IsNull(column)
+	}
+	return &compare{column, " = ", v}
+}
+
+// Neq returns a Filter which matches when column doesn't equal v. If v is nil, the Filter
+// matches when column IS NOT NULL.
+func Neq(column string, v interface{}) Filter {
+	if isNil(v) {
+		return &isNull{column, " IS NOT NULL"}
+	}
+	return &compare{column, " <> ", v}
+}
+
+// Lt returns a Filter which matches when column is less than v.
+func Lt(column string, v interface{}) Filter {
+	return &compare{column, " < ", v}
+}
+
+// Lte returns a Filter which matches when column is less than or equal to v.
+func Lte(column string, v interface{}) Filter {
+	return &compare{column, " <= ", v}
+}
+
+// Gt returns a Filter which matches when column is greater than v.
+func Gt(column string, v interface{}) Filter {
+	return &compare{column, " > ", v}
+}
+
+// Gte returns a Filter which matches when column is greater than or equal to v.
+func Gte(column string, v interface{}) Filter {
+	return &compare{column, " >= ", v}
+}
+
+// Like returns a Filter which matches when column matches the LIKE pattern.
+func Like(column string, pattern string) Filter {
+	return &compare{column, " LIKE ", pattern}
+}
+
+// In returns a Filter which matches when column equals one of the elements of slice,
+// which can be a slice of any element type. If slice is empty, the Filter is FALSE.
+func In(column string, slice interface{}) Filter {
+	return &in{column, slice}
+}
+
+// IsNull returns a Filter which matches when column IS NULL.
+func IsNull(column string) Filter {
+	return &isNull{column, " IS NULL"}
+}
+
+// And returns a Filter which matches when all filters match. Without filters, the Filter is TRUE.
+func And(filters ...Filter) Filter {
+	return &junction{" AND ", "TRUE", filters}
+}
+
+// Or returns a Filter which matches when any of the filters match. Without filters, the Filter is FALSE.
+func Or(filters ...Filter) Filter {
+	return &junction{" OR ", "FALSE", filters}
+}
+
+// Not returns a Filter which matches when f doesn't match.
+func Not(f Filter) Filter {
+	return &not{f}
+}
+
+type compare struct {
+	column, op string
+	v          interface{}
+}
+
+func (c *compare) WriteFilter(b *Builder) {
+	b.writeColumn(c.column)
+	b.Write(c.op).WriteWithParams("{}", c.v)
+}
+
+type in struct {
+	column string
+	slice  interface{}
+}
+
+func (c *in) WriteFilter(b *Builder) {
+	if v := reflect.ValueOf(c.slice); (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() == 0 {
+		b.Write("FALSE")
+		return
+	}
+	b.writeColumn(c.column)
+	b.WriteWithParams(" IN ({...})", c.slice)
+}
+
+type isNull struct {
+	column, op string
+}
+
+func (c *isNull) WriteFilter(b *Builder) {
+	b.writeColumn(c.column)
+	b.Write(c.op)
+}
+
+type junction struct {
+	sep, empty string
+	filters    []Filter
+}
+
+func (c *junction) WriteFilter(b *Builder) {
+	if len(c.filters) == 0 {
+		b.Write(c.empty)
+		return
+	}
+	writeJunction(b, c.sep, c.filters)
+}
+
+type not struct {
+	f Filter
+}
+
+func (c *not) WriteFilter(b *Builder) {
+	b.Write("NOT (")
+	c.f.WriteFilter(b)
+	b.Write(")")
+}
+
+// writeJunction writes every filter enclosed in parentheses, separated by sep.
+func writeJunction(b *Builder, sep string, filters []Filter) {
+	for i, f := range filters {
+		if 0 < i {
+			b.Write(sep)
+		}
+		b.Write("(")
+		f.WriteFilter(b)
+		b.Write(")")
+	}
+}
+
+// writeColumn writes the column name, every part is quoted when required by the dialect.
+func (b *Builder) writeColumn(column string) {
+	d := b.dialect()
+	for i, part := range strings.Split(column, ".") {
+		if 0 < i {
+			b.s.WriteByte('.')
+		}
+		if d.NeedsQuote(part) {
+			part = d.QuoteIdent(part)
+		}
+		b.s.WriteString(part)
+	}
+}
+
+// isNil returns whether v is nil or a nil pointer.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
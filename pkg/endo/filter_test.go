@@ -0,0 +1,73 @@
+package endo_test
+
+import (
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilters(t *testing.T) {
+	var (
+		b     endo.Builder
+		email *string
+	)
+
+	query, args := b.
+		Write("SELECT * FROM users WHERE ").
+		WriteFilters(
+			endo.Eq("active", true),
+			endo.Neq("users.role", "guest"),
+			endo.Eq("deleted_at", nil),
+			endo.Neq("email", email),
+			endo.Or(endo.Lt("age", 18), endo.Gte("age", 65)),
+			endo.Not(endo.Like("first_name", "J%")),
+			endo.KeyValue{Key: "last_name = {}", Value: "Doe"},
+		).
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, `SELECT * FROM users WHERE (active = $1) AND (users.role <> $2) AND (deleted_at IS NULL) AND (email IS NOT NULL) AND ((age < $3) OR (age >= $4)) AND (NOT (first_name LIKE $5)) AND (last_name = $6)`, query)
+	assert.Equal(t, []interface{}{true, "guest", 18, 65, "J%", "Doe"}, args)
+}
+
+func TestFilterIn(t *testing.T) {
+	b := endo.Builder{Dialect: endo.SQLite}
+
+	query, args := b.
+		Write("SELECT * FROM users WHERE ").
+		WriteFilters(
+			endo.In("id", []int{4, 5}),
+			endo.In("email", endo.Values{"a@example.com"}),
+			endo.KeyValue{Key: "active"},
+		).
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "SELECT * FROM users WHERE (id IN (?1, ?2)) AND (email IN (?3)) AND (active)", query)
+	assert.Equal(t, []interface{}{4, 5, "a@example.com"}, args)
+}
+
+func TestFilterInEmpty(t *testing.T) {
+	var b endo.Builder
+
+	query, args := b.
+		Write("SELECT * FROM users WHERE ").
+		WriteFilters(endo.In("id", []int{}), endo.Not(endo.In("id", []int{})), endo.And(), endo.Or()).
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "SELECT * FROM users WHERE (FALSE) AND (NOT (FALSE)) AND (TRUE) AND (FALSE)", query)
+	assert.Empty(t, args)
+}
+
+func TestFilterQuotesColumn(t *testing.T) {
+	b := endo.Builder{Dialect: endo.MySQL}
+
+	query := b.
+		WriteFilters(endo.Eq("key", 1), endo.Eq("id = 1 OR 1", 2), endo.IsNull("users.firstName")).
+		String()
+
+	assert.Equal(t, "(`key` = ?) AND (`id = 1 OR 1` = ?) AND (users.`firstName` IS NULL)", query)
+}
@@ -0,0 +1,108 @@
+package endo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// paramListRegexp matches a parenthesized list of normalized parameters.
+var paramListRegexp = regexp.MustCompile(`\( ?\?(?: ?, ?\?)* ?\)`)
+
+// Fingerprint returns a stable hash (16 hexadecimal characters) of the normalized query, see Normalize.
+// Queries with the same shape, but different arguments or parameter list lengths, have the same fingerprint.
+func Fingerprint(query string) string {
+	h := fnv.New64a()
+	h.Write([]byte(Normalize(query)))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// Normalize returns the normalized text of query, which is suitable for grouping queries by shape.
+// Whitespace is collapsed and comments are removed, every parameter ($1, ?1 or ?), string literal
+// and number literal is replaced by "?", and a parenthesized list of them, like the list of an IN
+// clause, is collapsed to "(...)". Quoted identifiers are kept as-is.
+func Normalize(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	space := false // whether whitespace is pending
+	write := func(s string) {
+		if space && b.Len() != 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+		case strings.HasPrefix(query[i:], "--"):
+			// Line comment.
+			if j := strings.IndexByte(query[i:], '\n'); j != -1 {
+				i += j
+			} else {
+				i = len(query)
+			}
+			space = true
+		case strings.HasPrefix(query[i:], "/*"):
+			// Block comment.
+			if j := strings.Index(query[i+2:], "*/"); j != -1 {
+				i += j + 4
+			} else {
+				i = len(query)
+			}
+			space = true
+		case c == '\'':
+			// String literal.
+			i = indexClosingQuote(query, i)
+			write("?")
+		case c == '"' || c == '`':
+			// Quoted identifier.
+			j := indexClosingQuote(query, i)
+			write(query[i:j])
+			i = j
+		case c == '$' || c == '?':
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			if c == '$' && j == i+1 {
+				write("$") // not a parameter
+			} else {
+				write("?")
+			}
+			i = j
+		case isDigit(c):
+			// Number literal.
+			j := i + 1
+			for j < len(query) && (isDigit(query[j]) || query[j] == '.') {
+				j++
+			}
+			write("?")
+			i = j
+		case isIdentChar(c):
+			// Keyword or identifier, which can contain digits.
+			j := i + 1
+			for j < len(query) && (isIdentChar(query[j]) || isDigit(query[j])) {
+				j++
+			}
+			write(query[i:j])
+			i = j
+		default:
+			write(query[i : i+1])
+			i++
+		}
+	}
+	return paramListRegexp.ReplaceAllString(b.String(), "(...)")
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '$' || 0x80 <= c
+}
@@ -0,0 +1,38 @@
+package endo_test
+
+import (
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		Query, Normalized string
+	}{
+		{"SELECT * FROM users WHERE id = $1", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT  *\n\tFROM users -- all users\nWHERE (id IN ($1, $2, $3)) AND (active = $4)", "SELECT * FROM users WHERE (id IN (...)) AND (active = ?)"},
+		{"SELECT * FROM users WHERE id IN (?1,?2) LIMIT 10 OFFSET 20", "SELECT * FROM users WHERE id IN (...) LIMIT ? OFFSET ?"},
+		{"/* DEBUG ONLY, DO NOT EXECUTE */ SELECT * FROM users WHERE email = 'o''reilly@example.com' AND score > 1.5", "SELECT * FROM users WHERE email = ? AND score > ?"},
+		{"SELECT \"table1\", `Col 2`, t2.col3 FROM t2 WHERE x = ?", "SELECT \"table1\", `Col 2`, t2.col3 FROM t2 WHERE x = ?"},
+	}
+
+	for _, test := range cases {
+		assert.Equal(t, test.Normalized, endo.Normalize(test.Query), test.Query)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	var a, b endo.Builder
+	a.Write("SELECT * FROM users WHERE ").WriteFilters(endo.In("id", []int{1, 2}), endo.Eq("email", "a@example.com"))
+	b.Write("SELECT *  FROM users WHERE ").WriteFilters(endo.In("id", []int{3, 4, 5}), endo.Eq("email", "b@example.com"))
+
+	fp := endo.Fingerprint(a.String())
+
+	assert.Len(t, fp, 16)
+	assert.Equal(t, fp, endo.Fingerprint(b.String()))
+	assert.Equal(t, fp, endo.Fingerprint(a.DebugString()))
+	assert.NotEqual(t, fp, endo.Fingerprint("SELECT * FROM roles WHERE id = $1"))
+}
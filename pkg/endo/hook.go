@@ -0,0 +1,126 @@
+package endo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// QueryInfo describes a query executed through a DBTX, see Hook.
+type QueryInfo struct {
+	// Method is the called method of DBTX, like "ExecContext".
+	Method string
+	// Query is the SQL query.
+	Query string
+	// Args are the arguments of the query. It's a copy, so it can be retained after the call.
+	Args []interface{}
+	// Attempt is the attempt of the transaction, if retried by WithRetry or the Retry option of UseDB
+	// (see RetryAttempt). It's set whether the hooks are added inside or outside of the retries.
+	Attempt int
+
+	// Duration is the execution time of the query. For QueryContext, it's the time until the
+	// rows are returned, the time to iterate the rows isn't included.
+	Duration time.Duration
+	// RowsAffected is the number of rows affected by ExecContext, or -1 if it's unknown.
+	RowsAffected int64
+	// Err is the error returned by the query.
+	Err error
+}
+
+// A Hook observes the queries executed through a DBTX, for example to log queries or collect metrics.
+// Hooks are added by WithHooks.
+type Hook interface {
+	// BeforeQuery is called before the query is executed. Duration, RowsAffected and Err aren't set
+	// yet. The returned context is passed to the query and AfterQuery, for example to trace the query.
+	BeforeQuery(ctx context.Context, q *QueryInfo) context.Context
+	// AfterQuery is called after the query is executed.
+	AfterQuery(ctx context.Context, q *QueryInfo)
+}
+
+// WithHooks wraps tx in a TxFunc which calls hooks around every query executed through the DBTX.
+// The hooks are called in order before the query, and in reverse order after the query. The
+// statements returned by PrepareContext aren't observed, only the prepare itself.
+func WithHooks(tx TxFunc, hooks ...Hook) TxFunc {
+	return func(ctx context.Context, flags uint, fn func(DBTX) error) error {
+		attempt := RetryAttempt(ctx)
+		// The attempt is updated by the retries inside tx, like UseDB(db, Retry(policy)).
+		ctx = context.WithValue(ctx, attemptObserverKey{}, &attempt)
+		return tx(ctx, flags, func(dbtx DBTX) error {
+			return fn(&hookedDBTX{dbtx, hooks, attempt})
+		})
+	}
+}
+
+// hookedDBTX is a DBTX which calls hooks around every query.
+type hookedDBTX struct {
+	dbtx    DBTX
+	hooks   []Hook
+	attempt int
+}
+
+// Unwrap returns the wrapped DBTX.
+func (h *hookedDBTX) Unwrap() DBTX {
+	return h.dbtx
+}
+
+func (h *hookedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	q := h.newQueryInfo("ExecContext", query, args)
+	ctx, start := h.before(ctx, q), time.Now()
+	res, err := h.dbtx.ExecContext(ctx, query, args...)
+	if err == nil {
+		if n, nErr := res.RowsAffected(); nErr == nil {
+			q.RowsAffected = n
+		}
+	}
+	h.after(ctx, q, start, err)
+	return res, err
+}
+
+func (h *hookedDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	q := h.newQueryInfo("PrepareContext", query, nil)
+	ctx, start := h.before(ctx, q), time.Now()
+	stmt, err := h.dbtx.PrepareContext(ctx, query)
+	h.after(ctx, q, start, err)
+	return stmt, err
+}
+
+func (h *hookedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	q := h.newQueryInfo("QueryContext", query, args)
+	ctx, start := h.before(ctx, q), time.Now()
+	rows, err := h.dbtx.QueryContext(ctx, query, args...)
+	h.after(ctx, q, start, err)
+	return rows, err
+}
+
+func (h *hookedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	q := h.newQueryInfo("QueryRowContext", query, args)
+	ctx, start := h.before(ctx, q), time.Now()
+	row := h.dbtx.QueryRowContext(ctx, query, args...)
+	h.after(ctx, q, start, row.Err())
+	return row
+}
+
+func (h *hookedDBTX) newQueryInfo(method, query string, args []interface{}) *QueryInfo {
+	return &QueryInfo{
+		Method:       method,
+		Query:        query,
+		Args:         append([]interface{}(nil), args...),
+		Attempt:      h.attempt,
+		RowsAffected: -1,
+	}
+}
+
+func (h *hookedDBTX) before(ctx context.Context, q *QueryInfo) context.Context {
+	for _, hook := range h.hooks {
+		ctx = hook.BeforeQuery(ctx, q)
+	}
+	return ctx
+}
+
+func (h *hookedDBTX) after(ctx context.Context, q *QueryInfo, start time.Time, err error) {
+	q.Duration = time.Since(start)
+	q.Err = err
+	for i := len(h.hooks) - 1; 0 <= i; i-- {
+		h.hooks[i].AfterQuery(ctx, q)
+	}
+}
@@ -0,0 +1,150 @@
+//go:build cgo
+// +build cgo
+
+package endo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hookKey struct{}
+
+// recordingHook records the queries and calls to the hook.
+type recordingHook struct {
+	name    string
+	calls   *[]string
+	queries []endo.QueryInfo
+}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, q *endo.QueryInfo) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return context.WithValue(ctx, hookKey{}, h.name)
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, q *endo.QueryInfo) {
+	*h.calls = append(*h.calls, "after "+h.name+" "+ctx.Value(hookKey{}).(string))
+	h.queries = append(h.queries, *q)
+}
+
+func TestWithHooks(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var calls []string
+	a, b := &recordingHook{name: "a", calls: &calls}, &recordingHook{name: "b", calls: &calls}
+	tx := endo.WithRetry(endo.WithHooks(endo.UseDB(db), a, b), fastRetry)
+
+	err := tx(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		if err := insertName(ctx, dbtx, "a"); err != nil {
+			return err
+		}
+		var n int
+		if err := dbtx.QueryRowContext(ctx, "SELECT COUNT(*) FROM names WHERE name = ?1", "a").Scan(&n); err != nil {
+			return err
+		}
+		rows, err := dbtx.QueryContext(ctx, "SELECT name FROM unknown")
+		if err == nil {
+			rows.Close()
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"before a", "before b", "after b b", "after a b",
+		"before a", "before b", "after b b", "after a b",
+		"before a", "before b", "after b b", "after a b",
+	}, calls)
+	require.Len(t, a.queries, 3)
+	assert.Equal(t, a.queries, b.queries)
+
+	q := a.queries[0]
+	assert.Equal(t, "ExecContext", q.Method)
+	assert.Equal(t, "INSERT INTO names (name) VALUES (?1)", q.Query)
+	assert.Equal(t, []interface{}{"a"}, q.Args)
+	assert.Equal(t, 1, q.Attempt)
+	assert.Equal(t, int64(1), q.RowsAffected)
+	assert.NoError(t, q.Err)
+	assert.Less(t, time.Duration(0), q.Duration)
+
+	q = a.queries[1]
+	assert.Equal(t, "QueryRowContext", q.Method)
+	assert.Equal(t, int64(-1), q.RowsAffected)
+	assert.NoError(t, q.Err)
+
+	q = a.queries[2]
+	assert.Equal(t, "QueryContext", q.Method)
+	assert.Error(t, q.Err)
+}
+
+func TestWithHooksSavepoint(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var calls []string
+	h := &recordingHook{name: "h", calls: &calls}
+
+	err := endo.WithHooks(endo.UseDB(db), h)(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		return endo.WrapTX(dbtx)(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			return insertName(ctx, dbtx, "a")
+		})
+	})
+
+	require.NoError(t, err)
+	require.Len(t, h.queries, 3)
+	assert.Regexp(t, "^SAVEPOINT ", h.queries[0].Query)
+	assert.Equal(t, "INSERT INTO names (name) VALUES (?1)", h.queries[1].Query)
+	assert.Regexp(t, "^RELEASE SAVEPOINT ", h.queries[2].Query)
+}
+
+func TestWithHooksRetryOption(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var calls []string
+	h := &recordingHook{name: "h", calls: &calls}
+	tx := endo.WithHooks(endo.UseDB(db, endo.Retry(fastRetry)), h)
+
+	attempt := 0
+	err := tx(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		if err := insertName(ctx, dbtx, "a"); err != nil {
+			return err
+		}
+		if attempt++; attempt == 1 {
+			return stateError("40001")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, h.queries, 2)
+	assert.Equal(t, 1, h.queries[0].Attempt)
+	assert.Equal(t, 2, h.queries[1].Attempt)
+	n, err := countNames(ctx, endo.UseDB(db), endo.TxReadOnly)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestWithHooksArgsCopied(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var calls []string
+	h := &recordingHook{name: "h", calls: &calls}
+
+	err := endo.WithHooks(endo.UseDB(db), h)(ctx, endo.TxMutation, func(dbtx endo.DBTX) error {
+		// The arguments of a pooled Builder are reused after the query.
+		qb := endo.AcquireBuilder(endo.SQLite)
+		query, args := qb.WriteWithParams("INSERT INTO names (name) VALUES ({})", "a").Build()
+		_, err := dbtx.ExecContext(ctx, query, args...)
+		endo.ReleaseBuilder(qb)
+		return err
+	})
+
+	require.NoError(t, err)
+	require.Len(t, h.queries, 1)
+	assert.Equal(t, []interface{}{"a"}, h.queries[0].Args)
+}
@@ -0,0 +1,143 @@
+package endo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WriteWithNamedParams substitutes every named parameter denoted by "{name}" in s to a parameter formatted
+// by Builder.FormatParam, and appends it along with the named argument from params, to the Builder's buffer.
+// The params can be a map[string]interface{}, or a struct (or pointer to a struct) of which the fields are
+// named by their "db" tag. When a name is used multiple times, the argument is only appended once if the
+// parameters are numbered (like FixedParam), otherwise the argument is appended for every use (like
+// QuestionMarkParam). If an argument is missing, the error is reported by Err. Returns the receiver Builder.
+func (b *Builder) WriteWithNamedParams(s string, params interface{}) *Builder {
+	if b.FormatParam == nil {
+		b.FormatParam = b.dialect().FormatParam
+	}
+	lookup, err := namedArgs(params)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	numbered := b.numberedParams()
+	used := make(map[string]int)
+	b.s.Grow(len(s))
+	for {
+		i, name := indexNamedParam(s)
+		if i == -1 {
+			break
+		}
+		b.s.WriteString(s[:i])
+		s = s[i+len(name)+2:] // advance
+		if index, ok := used[name]; ok && numbered {
+			b.writeParam(index)
+			continue
+		}
+		v, ok := lookup(name)
+		if !ok {
+			b.setErr(fmt.Errorf("%w: missing argument for named parameter {%s}", ErrTooFewArgs, name))
+			return b
+		}
+		used[name] = len(b.args)
+		b.writeParam(len(b.args))
+		b.args = append(b.args, v)
+	}
+	b.s.WriteString(s)
+	return b
+}
+
+// numberedParams returns whether the parameters formatted by FormatParam refer to the
+// argument by index. This is detected by formatting two different parameters.
+func (b *Builder) numberedParams() bool {
+	var p0, p1 Builder
+	b.FormatParam(&p0, 0)
+	b.FormatParam(&p1, 1)
+	return p0.String() != p1.String()
+}
+
+// indexNamedParam returns the index and name of the first named parameter in s,
+// or -1 if there is none.
+func indexNamedParam(s string) (int, string) {
+	offset := 0
+	for {
+		i := strings.IndexByte(s[offset:], '{')
+		if i == -1 {
+			return -1, ""
+		}
+		i += offset
+		j := strings.IndexByte(s[i:], '}')
+		if j == -1 {
+			return -1, ""
+		}
+		if name := s[i+1 : i+j]; isParamName(name) {
+			return i, name
+		}
+		offset = i + 1
+	}
+}
+
+// isParamName returns whether s is a valid parameter name.
+func isParamName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_':
+		case '0' <= c && c <= '9' && 0 < i:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// namedArgs returns a lookup function for the named arguments in params.
+func namedArgs(params interface{}) (func(name string) (interface{}, bool), error) {
+	if m, ok := params.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			v, ok := m[name]
+			return v, ok
+		}, nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(params))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("named parameters require a map[string]interface{} or struct, not %T", params)
+	}
+	fields := make(map[string]reflect.Value)
+	addStructFields(fields, v)
+	return func(name string) (interface{}, bool) {
+		field, ok := fields[name]
+		if !ok {
+			return nil, false
+		}
+		return field.Interface(), true
+	}, nil
+}
+
+// addStructFields adds the exported fields of struct v by their name in the "db" tag,
+// or field name when there is no tag. Fields of embedded structs are added as well.
+func addStructFields(fields map[string]reflect.Value, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// Unexported field.
+			continue
+		}
+		name := strings.Split(field.Tag.Get("db"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructFields(fields, v.Field(i))
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = v.Field(i)
+	}
+}
@@ -0,0 +1,92 @@
+package endo_test
+
+import (
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteWithNamedParamsMap(t *testing.T) {
+	var b endo.Builder
+
+	query, args := b.
+		Write("SELECT * FROM users ").
+		WriteWithNamedParams("WHERE email = {email} OR (backup_email = {email} AND active = {active})", map[string]interface{}{
+			"email":  "test@example.com",
+			"active": true,
+		}).
+		Build()
+
+	assert.Equal(t, "SELECT * FROM users WHERE email = $1 OR (backup_email = $1 AND active = $2)", query)
+	assert.Equal(t, []interface{}{"test@example.com", true}, args)
+}
+
+func TestWriteWithNamedParamsQuestionMark(t *testing.T) {
+	b := endo.Builder{FormatParam: endo.QuestionMarkParam}
+
+	query, args := b.
+		Write("SELECT * FROM users ").
+		WriteWithNamedParams("WHERE email = {email} OR (backup_email = {email} AND active = {active})", map[string]interface{}{
+			"email":  "test@example.com",
+			"active": true,
+		}).
+		Build()
+
+	assert.Equal(t, "SELECT * FROM users WHERE email = ? OR (backup_email = ? AND active = ?)", query)
+	assert.Equal(t, []interface{}{"test@example.com", "test@example.com", true}, args)
+}
+
+func TestWriteWithNamedParamsStruct(t *testing.T) {
+	type Base struct {
+		ID int `db:"id,readonly"`
+	}
+	params := struct {
+		Base
+		Email    string `db:"email"`
+		Username string
+		Password string `db:"-"`
+	}{
+		Base:     Base{ID: 3},
+		Email:    "test@example.com",
+		Username: "admin",
+		Password: "secret",
+	}
+	var b endo.Builder
+
+	query, args := b.
+		WriteWithParams("UPDATE users SET updated_at = {}", 1).
+		WriteWithNamedParams(", email = {email}, username = {Username} WHERE id = {id} AND email <> {email}", &params).
+		Build()
+
+	assert.Equal(t, "UPDATE users SET updated_at = $1, email = $2, username = $3 WHERE id = $4 AND email <> $2", query)
+	assert.Equal(t, []interface{}{1, "test@example.com", "admin", 3}, args)
+}
+
+func TestWriteWithNamedParamsIgnoresOtherBraces(t *testing.T) {
+	var b endo.Builder
+
+	query, args := b.
+		WriteWithNamedParams(`SELECT '{"a": 1}'::jsonb, '{}' WHERE id = {id}`, map[string]interface{}{"id": 5}).
+		Build()
+
+	assert.Equal(t, `SELECT '{"a": 1}'::jsonb, '{}' WHERE id = $1`, query)
+	assert.Equal(t, []interface{}{5}, args)
+}
+
+func TestWriteWithNamedParamsMissing(t *testing.T) {
+	var b endo.Builder
+
+	b.WriteWithNamedParams("WHERE id = {id}", map[string]interface{}{})
+
+	assert.ErrorIs(t, b.Err(), endo.ErrTooFewArgs)
+}
+
+func TestWriteWithNamedParamsInvalid(t *testing.T) {
+	var b endo.Builder
+
+	b.WriteWithNamedParams("WHERE id = {id}", 5)
+
+	assert.Error(t, b.Err())
+}
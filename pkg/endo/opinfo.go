@@ -0,0 +1,42 @@
+package endo
+
+import "context"
+
+// The operations of OpInfo.
+const (
+	OpSelect = "select"
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// OpInfo describes the store operation which executes the queries, for example to collect metrics per
+// model and operation in a Hook. The generated store methods attach an OpInfo to the context.
+type OpInfo struct {
+	// Model is the name of the model, like "User".
+	Model string
+	// Table is the table of the model, like "users".
+	Table string
+	// Operation is the operation on the model, like OpSelect.
+	Operation string
+	// Method is the store method, like "Store.GetUsers".
+	Method string
+}
+
+// opInfoKey is the context key of OpInfo.
+type opInfoKey struct{}
+
+// WithOpInfo returns a copy of ctx with info attached. If ctx already has an OpInfo of the same model
+// and operation, ctx is returned as-is, so a store method delegating to another keeps its own OpInfo.
+func WithOpInfo(ctx context.Context, info OpInfo) context.Context {
+	if cur, ok := OpInfoFromContext(ctx); ok && cur.Model == info.Model && cur.Operation == info.Operation {
+		return ctx
+	}
+	return context.WithValue(ctx, opInfoKey{}, info)
+}
+
+// OpInfoFromContext returns the OpInfo attached to ctx, if any.
+func OpInfoFromContext(ctx context.Context) (OpInfo, bool) {
+	info, ok := ctx.Value(opInfoKey{}).(OpInfo)
+	return info, ok
+}
@@ -0,0 +1,31 @@
+package endo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpInfo(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := endo.OpInfoFromContext(ctx)
+	assert.False(t, ok)
+
+	patchUser := endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpUpdate, Method: "Store.PatchUser"}
+	ctx = endo.WithOpInfo(ctx, patchUser)
+	info, ok := endo.OpInfoFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, patchUser, info)
+
+	// Delegating to another method of the same model and operation keeps the OpInfo.
+	info, _ = endo.OpInfoFromContext(endo.WithOpInfo(ctx, endo.OpInfo{Model: "User", Table: "users", Operation: endo.OpUpdate, Method: "Store.PatchUsers"}))
+	assert.Equal(t, patchUser, info)
+
+	getRoles := endo.OpInfo{Model: "Role", Table: "roles", Operation: endo.OpSelect, Method: "Store.GetRoles"}
+	info, _ = endo.OpInfoFromContext(endo.WithOpInfo(ctx, getRoles))
+	assert.Equal(t, getRoles, info)
+}
@@ -0,0 +1,39 @@
+package endo
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+// forcePrimaryKey is the context key denoting that reads must use the primary database.
+type forcePrimaryKey struct{}
+
+// ForcePrimary returns a copy of ctx which makes UseDBs send reads to the primary database,
+// for example to read your own writes after a mutation, since replicas can lag behind.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+// UseDBs wraps the primary database and its read replicas inside a transaction function handler,
+// see UseDB. Read-only calls (TxReadOnly) are sent to the replicas in round-robin order. Mutations
+// (TxMutation), calls inside a transaction of the context (see InTx) and calls with a context from
+// ForcePrimary are sent to the primary database. Without replicas, every call uses primary.
+func UseDBs(primary *sql.DB, replicas ...*sql.DB) TxFunc {
+	primaryTx := useDB(primary, nil)
+	if len(replicas) == 0 {
+		return primaryTx
+	}
+	replicaTxs := make([]TxFunc, len(replicas))
+	for i, replica := range replicas {
+		replicaTxs[i] = useDB(replica, nil)
+	}
+	var next uint64
+	return func(ctx context.Context, flags uint, fn func(DBTX) error) error {
+		if flags&TxMutation != 0 || ctx.Value(forcePrimaryKey{}) != nil || (hasTx(ctx) && flags&TxNew == 0) {
+			return primaryTx(ctx, flags, fn)
+		}
+		i := (atomic.AddUint64(&next, 1) - 1) % uint64(len(replicaTxs))
+		return replicaTxs[i](ctx, flags, fn)
+	}
+}
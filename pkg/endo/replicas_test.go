@@ -0,0 +1,54 @@
+//go:build cgo
+// +build cgo
+
+package endo_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dbName returns the name of the database, which is stored in the names table.
+func dbName(ctx context.Context, tx endo.TxFunc, flags uint) (name string, err error) {
+	err = tx(ctx, flags, func(dbtx endo.DBTX) error {
+		return dbtx.QueryRowContext(ctx, "SELECT name FROM names").Scan(&name)
+	})
+	return
+}
+
+func TestUseDBs(t *testing.T) {
+	ctx := context.Background()
+	dbs := make([]*sql.DB, 3)
+	for i, name := range []string{"primary", "replica1", "replica2"} {
+		dbs[i] = openDB(t)
+		require.NoError(t, insertName(ctx, dbs[i], name))
+	}
+	tx := endo.UseDBs(dbs[0], dbs[1:]...)
+
+	for _, want := range []string{"replica1", "replica2", "replica1"} {
+		name, err := dbName(ctx, tx, endo.TxReadOnly)
+		require.NoError(t, err)
+		assert.Equal(t, want, name)
+	}
+
+	name, err := dbName(ctx, tx, endo.TxMutation)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", name)
+
+	name, err = dbName(endo.ForcePrimary(ctx), tx, endo.TxReadOnly)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", name)
+
+	err = endo.InTx(ctx, dbs[0], func(ctx context.Context) error {
+		name, err := dbName(ctx, tx, endo.TxReadOnly|endo.TxMulti)
+		assert.Equal(t, "primary", name)
+		return err
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,172 @@
+package endo
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// A RetryPolicy describes when and how often a transaction is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// If MaxAttempts is zero, 3 attempts are made.
+	MaxAttempts int
+	// MinBackoff is the backoff before the first retry, which is doubled for every next retry.
+	// If MinBackoff is zero, 10ms is used.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum backoff. If MaxBackoff is zero, 1s is used.
+	MaxBackoff time.Duration
+	// Retryable returns whether the transaction can be retried after err.
+	// If Retryable is nil, IsRetryable is used.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy is the default RetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  10 * time.Millisecond,
+	MaxBackoff:  time.Second,
+	Retryable:   IsRetryable,
+}
+
+// retryAttemptKey is the context key of the current attempt.
+type retryAttemptKey struct{}
+
+// attemptObserverKey is the context key of the attempt observed by an enclosing WithHooks,
+// which is updated by WithRetry.
+type attemptObserverKey struct{}
+
+// joinObserverKey is the context key of the flag reporting to WithRetry that the transaction
+// of the context is joined, which is set by the TxFunc of UseDB.
+type joinObserverKey struct{}
+
+// observeJoin reports to an enclosing WithRetry that the transaction of ctx is joined.
+func observeJoin(ctx context.Context) {
+	if joined, ok := ctx.Value(joinObserverKey{}).(*bool); ok {
+		*joined = true
+	}
+}
+
+// RetryAttempt returns the attempt (starting at 1) of the transaction retried by WithRetry,
+// or 0 if ctx isn't retried.
+func RetryAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(retryAttemptKey{}).(int)
+	return attempt
+}
+
+// WithRetry wraps tx in a TxFunc which executes the transaction again, with jittered exponential
+// backoff, when it fails with a retryable error according to policy, like a serialization failure
+// or deadlock. The current attempt is available in the context passed to tx, see RetryAttempt,
+// and is reported to the hooks of an enclosing WithHooks as well.
+// Retrying stops when ctx is done. A call of UseDB joining the transaction of the context (see InTx)
+// isn't retried, since it can't be executed again on its own.
+func WithRetry(tx TxFunc, policy RetryPolicy) TxFunc {
+	policy = policy.withDefaults()
+	return func(ctx context.Context, flags uint, fn func(DBTX) error) error {
+		observed, _ := ctx.Value(attemptObserverKey{}).(*int)
+		joined := false
+		ctx = context.WithValue(ctx, joinObserverKey{}, &joined)
+		for attempt := 1; ; attempt++ {
+			if observed != nil {
+				*observed = attempt
+			}
+			err := tx(context.WithValue(ctx, retryAttemptKey{}, attempt), flags, fn)
+			if err == nil || joined || attempt == policy.MaxAttempts || !policy.Retryable(err) {
+				return err
+			}
+			t := time.NewTimer(policy.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.MinBackoff <= 0 {
+		p.MinBackoff = DefaultRetryPolicy.MinBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if p.Retryable == nil {
+		p.Retryable = DefaultRetryPolicy.Retryable
+	}
+	return p
+}
+
+// backoff returns the jittered backoff after attempt, which is between half and the full
+// exponential backoff.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff < d {
+		d = p.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryableCodes are the error codes of serialization failures, deadlocks and lock timeouts.
+var retryableCodes = map[string]struct{}{
+	"40001":      {}, // serialization_failure
+	"40P01":      {}, // deadlock_detected
+	"mysql:1213": {}, // ER_LOCK_DEADLOCK
+	"mysql:1205": {}, // ER_LOCK_WAIT_TIMEOUT
+	"sqlite:5":   {}, // SQLITE_BUSY
+	"sqlite:6":   {}, // SQLITE_LOCKED
+}
+
+// IsRetryable returns whether err is a serialization failure or deadlock, after which the
+// transaction can be retried. Errors of PostgreSQL (lib/pq and pgx), MySQL and SQLite drivers
+// are recognized.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrSerialization) {
+		return true
+	}
+	_, ok := retryableCodes[errorCode(err)]
+	return ok
+}
+
+// errorCode returns the code of the first driver error in the chain of err, or an empty string.
+func errorCode(err error) string {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if code := driverErrorCode(err); code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+// driverErrorCode returns the code of err if it's a driver error, or an empty string. PostgreSQL
+// errors are identified by their SQLSTATE, MySQL and SQLite errors by their error number prefixed
+// by "mysql:" and "sqlite:". The drivers are recognized without depending on them.
+func driverErrorCode(err error) string {
+	if e, ok := err.(interface{ SQLState() string }); ok {
+		// pgx and lib/pq.
+		return e.SQLState()
+	}
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	switch t := v.Type(); {
+	case t.PkgPath() == "github.com/lib/pq" && t.Name() == "Error":
+		return v.FieldByName("Code").String()
+	case t.PkgPath() == "github.com/go-sql-driver/mysql" && t.Name() == "MySQLError":
+		return "mysql:" + strconv.FormatUint(v.FieldByName("Number").Uint(), 10)
+	case t.PkgPath() == "github.com/mattn/go-sqlite3" && t.Name() == "Error":
+		return "sqlite:" + strconv.FormatInt(v.FieldByName("Code").Int(), 10)
+	}
+	return ""
+}
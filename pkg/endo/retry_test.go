@@ -0,0 +1,89 @@
+package endo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stateError is an error with a SQLSTATE, like the errors of pgx and lib/pq.
+type stateError string
+
+func (e stateError) Error() string    { return "error with state " + string(e) }
+func (e stateError) SQLState() string { return string(e) }
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, endo.IsRetryable(stateError("40001")))
+	assert.True(t, endo.IsRetryable(fmt.Errorf("commit transaction: %w", stateError("40P01"))))
+	assert.False(t, endo.IsRetryable(stateError("23505")))
+	assert.False(t, endo.IsRetryable(errors.New("40001")))
+	assert.False(t, endo.IsRetryable(nil))
+}
+
+// failingTx returns a TxFunc which fails with err for the first n attempts.
+func failingTx(n int, err error, attempts *[]int) endo.TxFunc {
+	return func(ctx context.Context, flags uint, fn func(endo.DBTX) error) error {
+		*attempts = append(*attempts, endo.RetryAttempt(ctx))
+		if len(*attempts) <= n {
+			return err
+		}
+		return fn(nil)
+	}
+}
+
+var fastRetry = endo.RetryPolicy{MinBackoff: time.Microsecond, MaxBackoff: time.Millisecond}
+
+func TestWithRetry(t *testing.T) {
+	var attempts []int
+	tx := endo.WithRetry(failingTx(2, stateError("40001"), &attempts), fastRetry)
+
+	called := false
+	err := tx(context.Background(), endo.TxMutation|endo.TxMulti, func(endo.DBTX) error {
+		called = true
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestWithRetryMaxAttempts(t *testing.T) {
+	var attempts []int
+	policy := fastRetry
+	policy.MaxAttempts = 2
+	tx := endo.WithRetry(failingTx(5, stateError("40P01"), &attempts), policy)
+
+	err := tx(context.Background(), endo.TxMulti, func(endo.DBTX) error { return nil })
+
+	assert.Equal(t, stateError("40P01"), err)
+	assert.Equal(t, []int{1, 2}, attempts)
+}
+
+func TestWithRetryNotRetryable(t *testing.T) {
+	var attempts []int
+	tx := endo.WithRetry(failingTx(1, stateError("23505"), &attempts), fastRetry)
+
+	err := tx(context.Background(), endo.TxMulti, func(endo.DBTX) error { return nil })
+
+	assert.Equal(t, stateError("23505"), err)
+	assert.Equal(t, []int{1}, attempts)
+}
+
+func TestWithRetryContextDone(t *testing.T) {
+	var attempts []int
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tx := endo.WithRetry(failingTx(1, stateError("40001"), &attempts), endo.RetryPolicy{MinBackoff: time.Hour})
+
+	err := tx(ctx, endo.TxMulti, func(endo.DBTX) error { return nil })
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []int{1}, attempts)
+}
@@ -0,0 +1,39 @@
+//go:build cgo
+// +build cgo
+
+package endo_test
+
+import (
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableSQLite(t *testing.T) {
+	assert.True(t, endo.IsRetryable(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	assert.True(t, endo.IsRetryable(&sqlite3.Error{Code: sqlite3.ErrLocked}))
+	assert.False(t, endo.IsRetryable(sqlite3.Error{Code: sqlite3.ErrConstraint}))
+}
+
+func TestClassifyErrorSQLite(t *testing.T) {
+	tests := []struct {
+		ext  sqlite3.ErrNoExtended
+		kind error
+	}{
+		{sqlite3.ErrConstraintUnique, endo.ErrUniqueViolation},
+		{sqlite3.ErrConstraintPrimaryKey, endo.ErrUniqueViolation},
+		{sqlite3.ErrConstraintForeignKey, endo.ErrForeignKeyViolation},
+		{sqlite3.ErrConstraintCheck, endo.ErrCheckViolation},
+		{sqlite3.ErrConstraintNotNull, endo.ErrNotNullViolation},
+	}
+	for _, tt := range tests {
+		err := endo.ClassifyError(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: tt.ext})
+		assert.ErrorIs(t, err, tt.kind)
+	}
+
+	err := sqlite3.Error{Code: sqlite3.ErrBusy}
+	assert.Equal(t, err, endo.ClassifyError(err))
+}
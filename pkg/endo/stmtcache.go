@@ -0,0 +1,275 @@
+package endo
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+// DefaultMaxStmts is the default maximum number of prepared statements of a StmtCache.
+const DefaultMaxStmts = 100
+
+// A StmtCache is a DBTX which executes queries using prepared statements. A statement is prepared
+// when the query is executed the first time, and cached for the next executions. The least recently
+// used statement is closed when the cache is full. Use UseDB with the Stmts option to use a StmtCache
+// within transactions. A StmtCache is safe for concurrent use.
+type StmtCache struct {
+	// MaxStmts is the maximum number of cached statements. If MaxStmts is zero, DefaultMaxStmts
+	// is used. MaxStmts must not be changed after the StmtCache is used.
+	MaxStmts int
+
+	db    *sql.DB
+	mu    sync.Mutex
+	lru   *list.List // of *cachedStmt, most recently used first
+	stmts map[string]*list.Element
+}
+
+type cachedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  // number of callers using stmt, guarded by StmtCache.mu
+	evicted bool // whether stmt must be closed when it isn't used anymore
+}
+
+// noStmtCacheKey is the context key denoting that the query must not be cached, because it's used once.
+type noStmtCacheKey struct{}
+
+// noStmtCache returns whether the query executed with ctx must not be cached.
+func noStmtCache(ctx context.Context) bool {
+	return ctx.Value(noStmtCacheKey{}) != nil
+}
+
+// CachedStmts returns a StmtCache for db.
+func CachedStmts(db *sql.DB) *StmtCache {
+	return &StmtCache{
+		db:    db,
+		lru:   list.New(),
+		stmts: make(map[string]*list.Element),
+	}
+}
+
+// stmt returns the cached statement of query, which is prepared if it isn't cached yet. The statement
+// isn't closed until it's released by the caller, see release.
+func (c *StmtCache) stmt(ctx context.Context, query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if e, ok := c.stmts[query]; ok {
+		c.lru.MoveToFront(e)
+		cs := e.Value.(*cachedStmt)
+		cs.refs++
+		c.mu.Unlock()
+		return cs, nil
+	}
+	c.mu.Unlock()
+
+	// Prepare without holding the lock, another goroutine can prepare the same query meanwhile.
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.stmts[query]; ok {
+		go stmt.Close()
+		c.lru.MoveToFront(e)
+		cs := e.Value.(*cachedStmt)
+		cs.refs++
+		return cs, nil
+	}
+	cs := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.stmts[query] = c.lru.PushFront(cs)
+	max := c.MaxStmts
+	if max <= 0 {
+		max = DefaultMaxStmts
+	}
+	for max < c.lru.Len() {
+		c.remove(c.lru.Back())
+	}
+	return cs, nil
+}
+
+// release releases the statement returned by stmt. The statement is closed if it was removed
+// from the cache meanwhile, and isn't used by another caller.
+func (c *StmtCache) release(cs *cachedStmt) {
+	c.mu.Lock()
+	cs.refs--
+	unused := cs.evicted && cs.refs == 0
+	c.mu.Unlock()
+	if unused {
+		cs.stmt.Close()
+	}
+}
+
+// remove removes the cached statement e, c.mu must be held. The statement is closed once it's
+// released by every caller using it; closing waits for the executions that are still running.
+func (c *StmtCache) remove(e *list.Element) {
+	cs := c.lru.Remove(e).(*cachedStmt)
+	delete(c.stmts, cs.query)
+	cs.evicted = true
+	if cs.refs == 0 {
+		go cs.stmt.Close()
+	}
+}
+
+// invalidate removes the cached statement of query if err is a connection error, or denotes
+// that the prepared statement is invalid. Returns err.
+func (c *StmtCache) invalidate(query string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errorCode(err) == "0A000" {
+		c.mu.Lock()
+		if e, ok := c.stmts[query]; ok {
+			c.remove(e)
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Len returns the number of cached statements.
+func (c *StmtCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Close closes all cached statements. A statement which is still in use is closed when it's released.
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		cs := e.Value.(*cachedStmt)
+		cs.evicted = true
+		if cs.refs != 0 {
+			continue
+		}
+		if closeErr := cs.stmt.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	c.lru.Init()
+	c.stmts = make(map[string]*list.Element)
+	return err
+}
+
+// ExecContext executes the cached statement of query.
+func (c *StmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if noStmtCache(ctx) {
+		return c.db.ExecContext(ctx, query, args...)
+	}
+	cs, err := c.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(cs)
+	res, err := cs.stmt.ExecContext(ctx, args...)
+	return res, c.invalidate(query, err)
+}
+
+// PrepareContext prepares query on the database, the statement isn't cached.
+func (c *StmtCache) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(ctx, query)
+}
+
+// QueryContext executes the cached statement of query.
+func (c *StmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	cs, err := c.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(cs)
+	rows, err := cs.stmt.QueryContext(ctx, args...)
+	return rows, c.invalidate(query, err)
+}
+
+// QueryRowContext executes the cached statement of query.
+func (c *StmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	cs, err := c.stmt(ctx, query)
+	if err != nil {
+		// Let the database return the error within a Row.
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	defer c.release(cs)
+	row := cs.stmt.QueryRowContext(ctx, args...)
+	c.invalidate(query, row.Err())
+	return row
+}
+
+// WithTx returns a DBTX which executes the cached statements within tx.
+func (c *StmtCache) WithTx(tx *sql.Tx) DBTX {
+	return &txStmts{cache: c, tx: tx}
+}
+
+// txStmts executes the cached statements within a transaction.
+type txStmts struct {
+	cache *StmtCache
+	tx    *sql.Tx
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt // statements bound to tx
+}
+
+// Unwrap returns the transaction.
+func (t *txStmts) Unwrap() DBTX {
+	return t.tx
+}
+
+// stmt returns the cached statement of query bound to the transaction.
+func (t *txStmts) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if stmt, ok := t.stmts[query]; ok {
+		return stmt, nil
+	}
+	cs, err := t.cache.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// The bound statement is closed when the transaction ends. The cached statement is held while
+	// binding, otherwise an eviction meanwhile would make the transaction prepare it again.
+	stmt := t.tx.StmtContext(ctx, cs.stmt)
+	t.cache.release(cs)
+	if t.stmts == nil {
+		t.stmts = make(map[string]*sql.Stmt)
+	}
+	t.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (t *txStmts) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if noStmtCache(ctx) {
+		return t.tx.ExecContext(ctx, query, args...)
+	}
+	stmt, err := t.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := stmt.ExecContext(ctx, args...)
+	return res, t.cache.invalidate(query, err)
+}
+
+func (t *txStmts) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.tx.PrepareContext(ctx, query)
+}
+
+func (t *txStmts) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := t.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	return rows, t.cache.invalidate(query, err)
+}
+
+func (t *txStmts) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := t.stmt(ctx, query)
+	if err != nil {
+		return t.tx.QueryRowContext(ctx, query, args...)
+	}
+	row := stmt.QueryRowContext(ctx, args...)
+	t.cache.invalidate(query, row.Err())
+	return row
+}
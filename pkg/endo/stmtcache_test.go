@@ -0,0 +1,111 @@
+//go:build cgo
+// +build cgo
+
+package endo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStmtCache(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	c := endo.CachedStmts(db)
+	c.MaxStmts = 2
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, insertName(ctx, c, fmt.Sprint(i)))
+	}
+	assert.Equal(t, 1, c.Len())
+
+	var n int
+	require.NoError(t, c.QueryRowContext(ctx, "SELECT COUNT(*) FROM names").Scan(&n))
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 2, c.Len())
+
+	rows, err := c.QueryContext(ctx, "SELECT name FROM names ORDER BY name")
+	require.NoError(t, err)
+	rows.Close()
+	assert.Equal(t, 2, c.Len(), "least recently used statement must be evicted")
+
+	_, err = c.QueryContext(ctx, "SELECT name FROM unknown")
+	assert.Error(t, err)
+	assert.Equal(t, 2, c.Len())
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestStmtCacheConcurrentEviction(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	c := endo.CachedStmts(db)
+	c.MaxStmts = 1
+	defer c.Close()
+
+	// Every query evicts the statement of another, which can still be in use.
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				var n int
+				query := fmt.Sprintf("SELECT %d + COUNT(*) FROM names", (i+j)%3)
+				if err := c.QueryRowContext(ctx, query).Scan(&n); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestUseDBStmts(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	c := endo.CachedStmts(db)
+	defer c.Close()
+	tx := endo.UseDB(db, endo.Stmts(c))
+
+	err := tx(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+		for _, name := range []string{"a", "b"} {
+			if err := insertName(ctx, dbtx, name); err != nil {
+				return err
+			}
+		}
+		// Nested inside a savepoint, since dbtx is a transaction.
+		return endo.WrapTX(dbtx)(ctx, endo.TxMutation|endo.TxMulti, func(dbtx endo.DBTX) error {
+			if err := insertName(ctx, dbtx, "c"); err != nil {
+				return err
+			}
+			return errors.New("rollback c")
+		})
+	})
+
+	assert.EqualError(t, err, "rollback c")
+	assert.Empty(t, selectNames(t, db))
+	assert.Equal(t, 1, c.Len())
+
+	n, err := countNames(ctx, tx, endo.TxReadOnly)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 2, c.Len())
+}
@@ -0,0 +1,112 @@
+package endo
+
+import "fmt"
+
+// A Template is a query with parameters denoted by "{}" (or "{...}", see WriteWithParams), which is parsed
+// once into literal and parameter segments. Rendering a Template doesn't scan the query again, this makes
+// it suitable for queries which are used often. A Template is safe for concurrent use.
+type Template struct {
+	literals []string // literal segments, surrounding the parameters
+	expand   []bool   // whether the parameter is expanded
+	size     int      // total length of the literals
+}
+
+// NewTemplate parses query into a Template.
+func NewTemplate(query string) *Template {
+	t := new(Template)
+	for {
+		i, expand := indexParam(query)
+		if i == -1 {
+			break
+		}
+		t.literals = append(t.literals, query[:i])
+		t.expand = append(t.expand, expand)
+		if expand {
+			query = query[i+5:]
+		} else {
+			query = query[i+2:]
+		}
+	}
+	t.literals = append(t.literals, query)
+	for _, lit := range t.literals {
+		t.size += len(lit)
+	}
+	return t
+}
+
+// NumParams returns the number of parameters in the Template.
+func (t *Template) NumParams() int {
+	return len(t.expand)
+}
+
+// Render returns the query of the Template for dialect d, with the parameters formatted by
+// d.FormatParam, along with the arguments a. If d is nil, Postgres is used.
+func (t *Template) Render(d *Dialect, a ...interface{}) (string, []interface{}, error) {
+	if !t.hasExpand() {
+		// Fast path, the arguments are used as-is.
+		if err := t.checkArgs(a); err != nil {
+			return "", nil, err
+		}
+		b := AcquireBuilder(d)
+		defer ReleaseBuilder(b)
+		d = b.dialect()
+		b.s.Grow(t.size + len(a)*paramSize)
+		for i, lit := range t.literals[:len(a)] {
+			b.s.WriteString(lit)
+			d.FormatParam(b, i)
+		}
+		b.s.WriteString(t.literals[len(a)])
+		return b.s.String(), a, nil
+	}
+	b := Builder{Dialect: d}
+	b.WriteTemplate(t, a...)
+	return b.s.String(), b.args, b.err
+}
+
+// WriteTemplate appends the query of t along with the arguments a to the Builder's buffer.
+// If the number of parameters and arguments differ, nothing is appended and the error is
+// reported by Err. Returns the receiver Builder.
+func (b *Builder) WriteTemplate(t *Template, a ...interface{}) *Builder {
+	if b.FormatParam == nil {
+		b.FormatParam = b.dialect().FormatParam
+	}
+	if err := t.checkArgs(a); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.s.Grow(t.size + len(a)*paramSize)
+	for i, lit := range t.literals[:len(a)] {
+		b.s.WriteString(lit)
+		if t.expand[i] {
+			b.writeExpandedParams(a[i])
+		} else {
+			b.writeParam(len(b.args))
+			b.args = append(b.args, a[i])
+		}
+	}
+	b.s.WriteString(t.literals[len(a)])
+	return b
+}
+
+// paramSize is the estimated length of a formatted parameter.
+const paramSize = 3
+
+func (t *Template) hasExpand() bool {
+	for _, expand := range t.expand {
+		if expand {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Template) checkArgs(a []interface{}) error {
+	if n := len(t.expand); n != len(a) {
+		err := ErrTooFewArgs
+		if n < len(a) {
+			err = ErrTooManyArgs
+		}
+		return fmt.Errorf("%w: template has %d parameter(s), got %d argument(s)", err, n, len(a))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package endo_test
+
+import (
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateRender(t *testing.T) {
+	tmpl := endo.NewTemplate("SELECT * FROM users WHERE email = {} OR display_name = {} LIMIT {}")
+
+	query, args, err := tmpl.Render(nil, "admin", "Admin", 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, tmpl.NumParams())
+	assert.Equal(t, "SELECT * FROM users WHERE email = $1 OR display_name = $2 LIMIT $3", query)
+	assert.Equal(t, []interface{}{"admin", "Admin", 10}, args)
+
+	query, _, err = tmpl.Render(endo.MySQL, "admin", "Admin", 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE email = ? OR display_name = ? LIMIT ?", query)
+}
+
+func TestTemplateRenderExpand(t *testing.T) {
+	tmpl := endo.NewTemplate("SELECT * FROM users WHERE id IN ({...}) AND active = {}")
+
+	query, args, err := tmpl.Render(endo.SQLite, []int{1, 2}, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id IN (?1, ?2) AND active = ?3", query)
+	assert.Equal(t, []interface{}{1, 2, true}, args)
+}
+
+func TestTemplateArgs(t *testing.T) {
+	tmpl := endo.NewTemplate("SELECT * FROM users WHERE id = {}")
+
+	_, _, err := tmpl.Render(nil)
+	assert.ErrorIs(t, err, endo.ErrTooFewArgs)
+
+	_, _, err = tmpl.Render(nil, 1, 2)
+	assert.ErrorIs(t, err, endo.ErrTooManyArgs)
+}
+
+func TestWriteTemplate(t *testing.T) {
+	tmpl := endo.NewTemplate(" ORDER BY id LIMIT {} OFFSET {}")
+	var b endo.Builder
+
+	query, args := b.
+		Write("SELECT * FROM users WHERE ").
+		WriteFilters(endo.Eq("active", true)).
+		WriteTemplate(tmpl, 10, 20).
+		Build()
+
+	assert.NoError(t, b.Err())
+	assert.Equal(t, "SELECT * FROM users WHERE (active = $1) ORDER BY id LIMIT $2 OFFSET $3", query)
+	assert.Equal(t, []interface{}{true, 10, 20}, args)
+}
+
+const benchmarkQuery = "SELECT id, email, first_name, last_name FROM users WHERE email = {} AND active = {} ORDER BY id LIMIT {} OFFSET {}"
+
+func BenchmarkBuilderWriteWithParams(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var qb endo.Builder
+		qb.WriteWithParams(benchmarkQuery, "jane@example.com", true, 10, 20)
+		qb.Build()
+	}
+}
+
+func BenchmarkTemplateRender(b *testing.B) {
+	tmpl := endo.NewTemplate(benchmarkQuery)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tmpl.Render(nil, "jane@example.com", true, 10, 20)
+	}
+}
+
+func BenchmarkBuilderGetUsers(b *testing.B) {
+	filters := []endo.Filter{endo.KeyValue{Key: "email = {}", Value: "jane@example.com"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var qb endo.Builder
+		qb.Write("SELECT id, email, first_name, last_name FROM users ")
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.Write(" ORDER BY id ").WriteLimitOffset(10, 20)
+		qb.Build()
+	}
+}
+
+func BenchmarkTemplateGetUsers(b *testing.B) {
+	filters := []endo.Filter{endo.KeyValue{Key: "email = {}", Value: "jane@example.com"}}
+	page := endo.NewTemplate(" ORDER BY id LIMIT {} OFFSET {}")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var qb endo.Builder
+		qb.Write("SELECT id, email, first_name, last_name FROM users ")
+		qb.Write("WHERE ").WriteFilters(filters...).Write(" ")
+		qb.WriteTemplate(page, 10, 20)
+		qb.Build()
+	}
+}
@@ -0,0 +1,28 @@
+package endo_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/semrekkers/endo/pkg/endo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxOptions(t *testing.T) {
+	cases := []struct {
+		Flags   uint
+		Options sql.TxOptions
+	}{
+		{endo.TxReadOnly, sql.TxOptions{ReadOnly: true}},
+		{endo.TxMutation | endo.TxMulti, sql.TxOptions{}},
+		{endo.TxMutation | endo.TxReadCommitted, sql.TxOptions{Isolation: sql.LevelReadCommitted}},
+		{endo.TxRepeatableRead, sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}},
+		{endo.TxSerializable | endo.TxDeferrable, sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}},
+		{endo.TxSerializable | endo.TxReadCommitted, sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}},
+	}
+
+	for _, test := range cases {
+		assert.Equal(t, &test.Options, endo.TxOptions(test.Flags), "flags: %b", test.Flags)
+	}
+}